@@ -0,0 +1,46 @@
+package gopay
+
+import (
+	"context"
+
+	accountApi "github.com/tkliner/go-gopay/apis/account"
+	"github.com/tkliner/go-gopay/client"
+	"github.com/tkliner/go-gopay/client/config"
+)
+
+type AccountGetter interface {
+	Account() AccountInterface
+}
+
+type AccountInterface interface {
+	// GetAccountStatement downloads the account statement file in the requested format.
+	// A zero GoId in the request is replaced by the GoID of the client.
+	GetAccountStatement(ctx context.Context, statement *accountApi.StatementRequest, opts ...CallOption) ([]byte, error)
+}
+
+type account struct {
+	client client.Interface
+	goId   int64
+}
+
+func newAccount(c client.Interface, goId int64) AccountInterface {
+	return &account{
+		client: c,
+		goId:   goId,
+	}
+}
+
+func (a *account) GetAccountStatement(ctx context.Context, statement *accountApi.StatementRequest, opts ...CallOption) ([]byte, error) {
+	s := *statement
+	if s.GoId == 0 {
+		s.GoId = a.goId
+	}
+
+	req := a.client.Post().
+		Resource(pathAccountStatement).
+		Scope(config.TokenScopeAll).
+		JSONBody(&s).
+		Accept(client.ContentTypeOctetStream, "*/*")
+
+	return applyOptions(req, opts).Do(ctx).Raw()
+}
@@ -0,0 +1,30 @@
+package account
+
+import (
+	"github.com/tkliner/go-gopay/apis/payment"
+)
+
+// StatementFormat is the file format of an account statement.
+type StatementFormat string
+
+const (
+	FormatXLSA StatementFormat = "XLS_A"
+	FormatXLSB StatementFormat = "XLS_B"
+	FormatXLSC StatementFormat = "XLS_C"
+	FormatCSVA StatementFormat = "CSV_A"
+	FormatCSVB StatementFormat = "CSV_B"
+	FormatCSVC StatementFormat = "CSV_C"
+	FormatCSVD StatementFormat = "CSV_D"
+	FormatABOA StatementFormat = "ABO_A"
+	FormatABOB StatementFormat = "ABO_B"
+)
+
+// StatementRequest selects the account statement to download. Dates use the
+// YYYY-MM-DD format.
+type StatementRequest struct {
+	DateFrom string           `json:"date_from"`
+	DateTo   string           `json:"date_to"`
+	GoId     int64            `json:"goid"`
+	Currency payment.Currency `json:"currency"`
+	Format   StatementFormat  `json:"format"`
+}
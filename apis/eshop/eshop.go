@@ -0,0 +1,36 @@
+package eshop
+
+import (
+	"github.com/tkliner/go-gopay/apis/payment"
+)
+
+// PaymentInstrumentsResponse lists the payment methods enabled for the e-shop in one currency.
+type PaymentInstrumentsResponse struct {
+	Groups                    map[string]Group    `json:"groups"`
+	EnabledPaymentInstruments []EnabledInstrument `json:"enabledPaymentInstruments"`
+}
+
+// Group is a group of payment instruments shown together on the gateway.
+type Group struct {
+	Label map[string]string `json:"label"`
+}
+
+type EnabledInstrument struct {
+	PaymentInstrument payment.PaymentInstrument `json:"paymentInstrument"`
+	Label             map[string]string         `json:"label"`
+	Image             *Image                    `json:"image,omitempty"`
+	Group             string                    `json:"group"`
+	EnabledSwifts     []EnabledSwift            `json:"enabledSwifts,omitempty"`
+}
+
+type EnabledSwift struct {
+	Swift    payment.Swift     `json:"swift"`
+	Label    map[string]string `json:"label"`
+	Image    *Image            `json:"image,omitempty"`
+	IsOnline bool              `json:"isOnline"`
+}
+
+type Image struct {
+	Normal string `json:"normal"`
+	Large  string `json:"large"`
+}
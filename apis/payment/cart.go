@@ -0,0 +1,198 @@
+package payment
+
+import (
+	"fmt"
+)
+
+// CartLine is a single product in the cart.
+type CartLine struct {
+	Name       string
+	UnitPrice  Money
+	Quantity   int
+	VatRate    int
+	ProductURL string
+	EAN        string
+}
+
+// Cart converts an e-shop basket into GoPay order items. Goods, shipping and discounts
+// become ITEM, DELIVERY and DISCOUNT items respectively.
+//
+//	items, total, err := payment.NewCart(payment.CZK).
+//		Add(payment.CartLine{Name: "Book", UnitPrice: price, Quantity: 2, VatRate: 12}).
+//		Shipping("PPL", shipping, 21).
+//		Discount("Voucher", voucher, 12).
+//		Items()
+type Cart struct {
+	currency Currency
+	items    []Item
+	err      error
+}
+
+// NewCart creates an empty cart in the given currency.
+func NewCart(currency Currency) *Cart {
+	c := &Cart{currency: currency}
+	if !currency.Valid() {
+		c.err = fmt.Errorf("unsupported currency %q", currency)
+	}
+	return c
+}
+
+// Add appends a product line. The item amount is UnitPrice multiplied by Quantity.
+func (c *Cart) Add(line CartLine) *Cart {
+	if line.Quantity <= 0 {
+		c.fail(fmt.Errorf("cart line %q: quantity must be positive", line.Name))
+		return c
+	}
+	if err := c.checkCurrency(line.Name, line.UnitPrice); err != nil {
+		return c
+	}
+
+	total, err := line.UnitPrice.Mul(int64(line.Quantity))
+	if err != nil {
+		c.fail(fmt.Errorf("cart line %q: %w", line.Name, err))
+		return c
+	}
+
+	c.items = append(c.items, Item{
+		Type:       ItemTypeItem,
+		Name:       line.Name,
+		Amount:     total.Amount(),
+		Count:      line.Quantity,
+		VatRate:    line.VatRate,
+		ProductURL: line.ProductURL,
+		EAN:        line.EAN,
+	})
+	return c
+}
+
+// Shipping appends a delivery item.
+func (c *Cart) Shipping(name string, price Money, vatRate int) *Cart {
+	if err := c.checkCurrency(name, price); err != nil {
+		return c
+	}
+	c.items = append(c.items, Item{
+		Type:    ItemTypeDelivery,
+		Name:    name,
+		Amount:  price.Amount(),
+		Count:   1,
+		VatRate: vatRate,
+	})
+	return c
+}
+
+// Discount appends a discount item. The amount may be given with either sign, it is
+// always sent as a negative value so that the items add up to the payment amount.
+func (c *Cart) Discount(name string, amount Money, vatRate int) *Cart {
+	if err := c.checkCurrency(name, amount); err != nil {
+		return c
+	}
+	if !amount.IsNegative() {
+		amount = amount.Neg()
+	}
+	c.items = append(c.items, Item{
+		Type:    ItemTypeDiscount,
+		Name:    name,
+		Amount:  amount.Amount(),
+		Count:   1,
+		VatRate: vatRate,
+	})
+	return c
+}
+
+// Items returns the order items and their total, which should be used as the payment amount.
+func (c *Cart) Items() ([]Item, Money, error) {
+	if c.err != nil {
+		return nil, Money{}, c.err
+	}
+
+	total := Money{currency: c.currency}
+	for _, item := range c.items {
+		var err error
+		if total, err = total.Add(Money{amount: item.Amount, currency: c.currency}); err != nil {
+			return nil, Money{}, err
+		}
+	}
+
+	items := make([]Item, len(c.items))
+	copy(items, c.items)
+	return items, total, nil
+}
+
+// ItemsForTotal returns the order items adjusted so that they add up exactly to total.
+// It is meant for totals rounded by the e-shop (e.g. to whole crowns): the difference is
+// spread over the goods proportionally to their amounts. Differences of one major unit
+// per product line or more are not rounding and are reported as an error.
+func (c *Cart) ItemsForTotal(total Money) ([]Item, error) {
+	items, sum, err := c.Items()
+	if err != nil {
+		return nil, err
+	}
+
+	diff, err := total.Sub(sum)
+	if err != nil {
+		return nil, err
+	}
+	if diff.IsZero() {
+		return items, nil
+	}
+
+	var goods []int
+	var weights []int64
+	for i, item := range items {
+		if item.Type == ItemTypeItem {
+			goods = append(goods, i)
+			weights = append(weights, int64(item.Amount))
+		}
+	}
+	if len(goods) == 0 {
+		return nil, fmt.Errorf("cart total %s differs from items sum %s and there are no goods to adjust", total, sum)
+	}
+
+	limit := Amount(len(goods))
+	for i := 0; i < total.Currency().Exponent(); i++ {
+		limit *= 10
+	}
+	if d := diff.Amount(); d >= limit || d <= -limit {
+		return nil, fmt.Errorf("cart total %s differs from items sum %s by more than rounding", total, sum)
+	}
+
+	if allZero(weights) {
+		for i := range weights {
+			weights[i] = 1
+		}
+	}
+
+	parts, err := diff.Allocate(weights...)
+	if err != nil {
+		return nil, err
+	}
+	for i, idx := range goods {
+		items[idx].Amount += parts[i].Amount()
+	}
+
+	return items, nil
+}
+
+func (c *Cart) checkCurrency(name string, m Money) error {
+	if m.Currency() != c.currency {
+		err := fmt.Errorf("cart line %q: %w: %s and %s", name, ErrCurrencyMismatch, m.Currency(), c.currency)
+		c.fail(err)
+		return err
+	}
+	return nil
+}
+
+func (c *Cart) fail(err error) {
+	if c.err == nil {
+		c.err = err
+	}
+}
+
+func allZero(values []int64) bool {
+	for _, v := range values {
+		if v != 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,79 @@
+package payment
+
+import (
+	"testing"
+)
+
+func TestMoneyAllocate(t *testing.T) {
+	parts, err := MustParseMoney("1.00", CZK).Allocate(1, 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parts[0].Amount() != 34 || parts[1].Amount() != 33 || parts[2].Amount() != 33 {
+		t.Errorf("unexpected allocation %v", parts)
+	}
+
+	parts, err = MustParseMoney("-0.05", CZK).Allocate(3, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parts[0].Amount()+parts[1].Amount() != -5 {
+		t.Errorf("allocation lost units: %v", parts)
+	}
+}
+
+func TestCartItems(t *testing.T) {
+	items, total, err := NewCart(CZK).
+		Add(CartLine{Name: "Book", UnitPrice: MustParseMoney("199.90", CZK), Quantity: 2, VatRate: 12, EAN: "9788071234562"}).
+		Add(CartLine{Name: "Pen", UnitPrice: MustParseMoney("15.33", CZK), Quantity: 1, VatRate: 21}).
+		Shipping("PPL", MustParseMoney("89", CZK), 21).
+		Discount("Voucher", MustParseMoney("50", CZK), 12).
+		Items()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if total.Decimal() != "454.13" {
+		t.Errorf("unexpected total %s", total)
+	}
+	if items[3].Type != ItemTypeDiscount || items[3].Amount != -5000 {
+		t.Errorf("unexpected discount item %+v", items[3])
+	}
+
+	p := validPayment()
+	p.Items = items
+	p.SetMoney(total)
+	if err := p.Validate(); err != nil {
+		t.Errorf("cart items should form a valid payment: %v", err)
+	}
+}
+
+func TestCartItemsForTotal(t *testing.T) {
+	cart := NewCart(CZK).
+		Add(CartLine{Name: "Book", UnitPrice: MustParseMoney("199.90", CZK), Quantity: 2, VatRate: 12}).
+		Add(CartLine{Name: "Pen", UnitPrice: MustParseMoney("15.33", CZK), Quantity: 1, VatRate: 21}).
+		Shipping("PPL", MustParseMoney("89", CZK), 21)
+
+	items, err := cart.ItemsForTotal(MustParseMoney("504", CZK))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var sum Amount
+	for _, item := range items {
+		sum += item.Amount
+	}
+	if sum != 50400 {
+		t.Errorf("items sum %d does not match total", sum)
+	}
+	if items[2].Amount != 8900 {
+		t.Errorf("shipping must not be adjusted, got %d", items[2].Amount)
+	}
+
+	if _, err := cart.ItemsForTotal(MustParseMoney("600", CZK)); err == nil {
+		t.Error("expected error for difference larger than rounding")
+	}
+	if _, _, err := NewCart(CZK).Shipping("PPL", MustParseMoney("1", EUR), 21).Items(); err == nil {
+		t.Error("expected currency mismatch error")
+	}
+}
@@ -0,0 +1,72 @@
+package payment
+
+import "fmt"
+
+// Currency is an ISO 4217 currency code accepted by the GoPay gateway.
+type Currency string
+
+const (
+	// Czech koruna
+	CZK Currency = "CZK"
+	// Euro
+	EUR Currency = "EUR"
+	// Polish złoty
+	PLN Currency = "PLN"
+	// US dollar
+	USD Currency = "USD"
+	// Pound sterling
+	GBP Currency = "GBP"
+	// Hungarian forint
+	HUF Currency = "HUF"
+	// Romanian leu
+	RON Currency = "RON"
+	// Bulgarian lev
+	BGN Currency = "BGN"
+)
+
+// currencyInfo holds the metadata GoPay needs to convert between major and minor units.
+type currencyInfo struct {
+	// Exponent is the number of decimal places of the minor unit.
+	Exponent int
+	// Symbol is used only for human readable formatting.
+	Symbol string
+}
+
+// currencies lists all currencies supported by GoPay. GoPay expects amounts in
+// hundredths for every currency, including HUF.
+var currencies = map[Currency]currencyInfo{
+	CZK: {Exponent: 2, Symbol: "Kč"},
+	EUR: {Exponent: 2, Symbol: "€"},
+	PLN: {Exponent: 2, Symbol: "zł"},
+	USD: {Exponent: 2, Symbol: "$"},
+	GBP: {Exponent: 2, Symbol: "£"},
+	HUF: {Exponent: 2, Symbol: "Ft"},
+	RON: {Exponent: 2, Symbol: "lei"},
+	BGN: {Exponent: 2, Symbol: "лв"},
+}
+
+// Valid reports whether the currency is supported by GoPay.
+func (c Currency) Valid() bool {
+	_, ok := currencies[c]
+	return ok
+}
+
+// Exponent returns the number of decimal places of the currency minor unit,
+// e.g. 2 for CZK (1 Kč = 100 haléřů).
+func (c Currency) Exponent() int {
+	return currencies[c].Exponent
+}
+
+// Symbol returns the display symbol of the currency.
+func (c Currency) Symbol() string {
+	return currencies[c].Symbol
+}
+
+// ParseCurrency converts a currency code to a Currency and fails for codes GoPay does not support.
+func ParseCurrency(code string) (Currency, error) {
+	c := Currency(code)
+	if !c.Valid() {
+		return "", fmt.Errorf("unsupported currency %q", code)
+	}
+	return c, nil
+}
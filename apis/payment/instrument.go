@@ -0,0 +1,81 @@
+package payment
+
+// PaymentInstrument identifies a payment method offered on the GoPay gateway.
+type PaymentInstrument string
+
+const (
+	// Payment card
+	PaymentCard PaymentInstrument = "PAYMENT_CARD"
+	// Bank transfer
+	BankAccount PaymentInstrument = "BANK_ACCOUNT"
+	// Premium SMS
+	PremiumSMS PaymentInstrument = "PRSMS"
+	// Mobile phone payment
+	MPayment PaymentInstrument = "MPAYMENT"
+	// paysafecard coupon
+	Paysafecard PaymentInstrument = "PAYSAFECARD"
+	// Google Pay
+	GPay PaymentInstrument = "GPAY"
+	// Apple Pay
+	ApplePay PaymentInstrument = "APPLE_PAY"
+	// PayPal account
+	PayPal PaymentInstrument = "PAYPAL"
+	// Bitcoin wallet
+	Bitcoin PaymentInstrument = "BITCOIN"
+	// Click to Pay
+	ClickToPay PaymentInstrument = "CLICK_TO_PAY"
+	// Twisto deferred payment
+	Twisto PaymentInstrument = "TWISTO"
+	// Skip Pay deferred payment
+	SkipPay PaymentInstrument = "SKIPPAY"
+)
+
+// Swift is the SWIFT/BIC code of a bank available for fast bank transfers.
+type Swift string
+
+const (
+	// Česká spořitelna
+	SwiftCeskaSporitelna Swift = "GIBACZPX"
+	// Komerční banka
+	SwiftKomercniBanka Swift = "KOMBCZPP"
+	// Raiffeisenbank
+	SwiftRaiffeisenbank Swift = "RZBCCZPP"
+	// mBank
+	SwiftMBank Swift = "BREXCZPP"
+	// Fio banka
+	SwiftFioBanka Swift = "FIOBCZPP"
+	// ČSOB
+	SwiftCSOB Swift = "CEKOCZPP"
+	// ERA - Poštovní spořitelna
+	SwiftEra Swift = "CEKOCZPP-ERA"
+	// MONETA Money Bank
+	SwiftMoneta Swift = "AGBACZPP"
+	// UniCredit Bank CZ
+	SwiftUniCreditCZ Swift = "BACXCZPP"
+	// Air Bank
+	SwiftAirBank Swift = "AIRACZPP"
+	// ING Bank
+	SwiftING Swift = "INGBCZPP"
+	// Slovenská sporiteľňa
+	SwiftSlovenskaSporitelna Swift = "GIBASKBX"
+	// Tatra banka
+	SwiftTatraBanka Swift = "TATRSKBX"
+	// VÚB banka
+	SwiftVUB Swift = "SUBASKBX"
+	// UniCredit Bank SK
+	SwiftUniCreditSK Swift = "UNCRSKBX"
+	// ČSOB SK
+	SwiftCSOBSK Swift = "CEKOSKBX"
+	// Poštová banka
+	SwiftPostovaBanka Swift = "POBNSKBA"
+	// OTP Banka
+	SwiftOTP Swift = "OTPVSKBX"
+	// Prima banka
+	SwiftPrimaBanka Swift = "KOMASK2X"
+	// Fio banka SK
+	SwiftFioBankaSK Swift = "FIOZSKBA"
+	// mBank SK
+	SwiftMBankSK Swift = "BREXSKBX"
+	// Any other bank, the payer fills in the transfer manually
+	SwiftOthers Swift = "OTHERS"
+)
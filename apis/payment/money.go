@@ -0,0 +1,280 @@
+package payment
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrCurrencyMismatch is returned when an operation combines amounts in different currencies.
+	ErrCurrencyMismatch = errors.New("currency mismatch")
+	// ErrAmountOverflow is returned when the result does not fit into the amount range.
+	ErrAmountOverflow = errors.New("amount overflow")
+)
+
+// Amount is a monetary value in minor units of its currency (haléře for CZK, cents for EUR).
+// It is what GoPay expects on the wire.
+type Amount int64
+
+// Money is an amount bound to its currency. The zero value is not valid, use
+// NewMoney or ParseMoney to construct it.
+type Money struct {
+	amount   Amount
+	currency Currency
+}
+
+// NewMoney creates Money from an amount already expressed in minor units.
+func NewMoney(minor Amount, currency Currency) (Money, error) {
+	if !currency.Valid() {
+		return Money{}, fmt.Errorf("unsupported currency %q", currency)
+	}
+	return Money{amount: minor, currency: currency}, nil
+}
+
+// ParseMoney creates Money from a decimal string in major units, e.g. "1234.50" CZK
+// becomes 123450 haléřů. Both '.' and ',' are accepted as decimal separator. Values with
+// more decimal places than the currency supports are rejected rather than rounded.
+func ParseMoney(value string, currency Currency) (Money, error) {
+	if !currency.Valid() {
+		return Money{}, fmt.Errorf("unsupported currency %q", currency)
+	}
+
+	s := strings.TrimSpace(value)
+	negative := false
+	switch {
+	case strings.HasPrefix(s, "-"):
+		negative = true
+		s = s[1:]
+	case strings.HasPrefix(s, "+"):
+		s = s[1:]
+	}
+
+	s = strings.Replace(s, ",", ".", 1)
+	whole, frac, _ := strings.Cut(s, ".")
+	exp := currency.Exponent()
+
+	if whole == "" && frac == "" {
+		return Money{}, fmt.Errorf("invalid amount %q", value)
+	}
+	if len(frac) > exp {
+		return Money{}, fmt.Errorf("invalid amount %q: %s allows at most %d decimal places", value, currency, exp)
+	}
+	if !isDigits(whole) || !isDigits(frac) {
+		return Money{}, fmt.Errorf("invalid amount %q", value)
+	}
+
+	var minor int64
+	if digits := strings.TrimLeft(whole+frac+strings.Repeat("0", exp-len(frac)), "0"); digits != "" {
+		v, err := strconv.ParseInt(digits, 10, 64)
+		if err != nil {
+			return Money{}, fmt.Errorf("invalid amount %q: %w", value, ErrAmountOverflow)
+		}
+		minor = v
+	}
+
+	if negative {
+		minor = -minor
+	}
+
+	return Money{amount: Amount(minor), currency: currency}, nil
+}
+
+// MustParseMoney is like ParseMoney but panics on error. It is intended for constants and tests.
+func MustParseMoney(value string, currency Currency) Money {
+	m, err := ParseMoney(value, currency)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Amount returns the value in minor units.
+func (m Money) Amount() Amount {
+	return m.amount
+}
+
+// Currency returns the currency of the value.
+func (m Money) Currency() Currency {
+	return m.currency
+}
+
+// IsZero reports whether the amount is zero.
+func (m Money) IsZero() bool {
+	return m.amount == 0
+}
+
+// IsNegative reports whether the amount is below zero.
+func (m Money) IsNegative() bool {
+	return m.amount < 0
+}
+
+// Add returns m + o. Both values must be in the same currency.
+func (m Money) Add(o Money) (Money, error) {
+	if m.currency != o.currency {
+		return Money{}, fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.currency, o.currency)
+	}
+	if (o.amount > 0 && m.amount > math.MaxInt64-o.amount) || (o.amount < 0 && m.amount < math.MinInt64-o.amount) {
+		return Money{}, ErrAmountOverflow
+	}
+	return Money{amount: m.amount + o.amount, currency: m.currency}, nil
+}
+
+// Sub returns m - o. Both values must be in the same currency.
+func (m Money) Sub(o Money) (Money, error) {
+	if o.amount == math.MinInt64 {
+		return Money{}, ErrAmountOverflow
+	}
+	return m.Add(o.Neg())
+}
+
+// Mul returns m multiplied by n, e.g. unit price times quantity.
+func (m Money) Mul(n int64) (Money, error) {
+	if m.amount == 0 || n == 0 {
+		return Money{amount: 0, currency: m.currency}, nil
+	}
+	r := int64(m.amount) * n
+	if r/n != int64(m.amount) || (n == -1 && m.amount == math.MinInt64) {
+		return Money{}, ErrAmountOverflow
+	}
+	return Money{amount: Amount(r), currency: m.currency}, nil
+}
+
+// Neg returns the value with the opposite sign.
+func (m Money) Neg() Money {
+	return Money{amount: -m.amount, currency: m.currency}
+}
+
+// Cmp compares m and o and returns -1, 0 or +1. Both values must be in the same currency.
+func (m Money) Cmp(o Money) (int, error) {
+	if m.currency != o.currency {
+		return 0, fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.currency, o.currency)
+	}
+	switch {
+	case m.amount < o.amount:
+		return -1, nil
+	case m.amount > o.amount:
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// Equal reports whether both values have the same amount and currency.
+func (m Money) Equal(o Money) bool {
+	return m == o
+}
+
+// Allocate splits m into parts proportional to ratios without losing a single minor unit.
+// Remainders left after integer division go to the parts with the largest fractional share.
+func (m Money) Allocate(ratios ...int64) ([]Money, error) {
+	if len(ratios) == 0 {
+		return nil, errors.New("allocate: no ratios given")
+	}
+
+	total := new(big.Int)
+	for _, r := range ratios {
+		if r < 0 {
+			return nil, errors.New("allocate: ratios must not be negative")
+		}
+		total.Add(total, big.NewInt(r))
+	}
+	if total.Sign() == 0 {
+		return nil, errors.New("allocate: sum of ratios must be positive")
+	}
+
+	amount := big.NewInt(int64(m.amount))
+	parts := make([]Money, len(ratios))
+	remainders := make([]*big.Int, len(ratios))
+	allocated := int64(0)
+
+	for i, r := range ratios {
+		share, rem := new(big.Int).QuoRem(new(big.Int).Mul(amount, big.NewInt(r)), total, new(big.Int))
+		parts[i] = Money{amount: Amount(share.Int64()), currency: m.currency}
+		remainders[i] = rem.Abs(rem)
+		allocated += share.Int64()
+	}
+
+	order := make([]int, len(ratios))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return remainders[order[a]].Cmp(remainders[order[b]]) > 0
+	})
+
+	left := int64(m.amount) - allocated
+	step := Amount(1)
+	if left < 0 {
+		step, left = -1, -left
+	}
+	for i := int64(0); i < left; i++ {
+		parts[order[i]].amount += step
+	}
+
+	return parts, nil
+}
+
+// Decimal formats the amount in major units without the currency, e.g. "1234.50".
+func (m Money) Decimal() string {
+	exp := m.currency.Exponent()
+	v := int64(m.amount)
+
+	sign := ""
+	u := uint64(v)
+	if v < 0 {
+		sign = "-"
+		u = uint64(-(v + 1)) + 1
+	}
+
+	s := strconv.FormatUint(u, 10)
+	if exp == 0 {
+		return sign + s
+	}
+	if len(s) <= exp {
+		s = strings.Repeat("0", exp-len(s)+1) + s
+	}
+	return sign + s[:len(s)-exp] + "." + s[len(s)-exp:]
+}
+
+// String formats the value for humans, e.g. "1234.50 CZK".
+func (m Money) String() string {
+	return m.Decimal() + " " + string(m.currency)
+}
+
+type moneyJSON struct {
+	Amount   Amount   `json:"amount"`
+	Currency Currency `json:"currency"`
+}
+
+// MarshalJSON encodes Money with the same amount and currency fields GoPay uses on payments.
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(moneyJSON{Amount: m.amount, Currency: m.currency})
+}
+
+// UnmarshalJSON decodes Money from the GoPay amount and currency fields.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	var v moneyJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	money, err := NewMoney(v.Amount, v.Currency)
+	if err != nil {
+		return err
+	}
+	*m = money
+	return nil
+}
@@ -0,0 +1,112 @@
+package payment
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Amount
+		wantErr bool
+	}{
+		{in: "100", want: 10000},
+		{in: "100.5", want: 10050},
+		{in: "100,50", want: 10050},
+		{in: "0.01", want: 1},
+		{in: "0", want: 0},
+		{in: "-12.34", want: -1234},
+		{in: ".5", want: 50},
+		{in: "1.005", wantErr: true},
+		{in: "1.2.3", wantErr: true},
+		{in: "abc", wantErr: true},
+		{in: "", wantErr: true},
+		{in: "92233720368547758.08", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		m, err := ParseMoney(tt.in, CZK)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseMoney(%q) expected error, got %v", tt.in, m)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseMoney(%q) unexpected error: %v", tt.in, err)
+			continue
+		}
+		if m.Amount() != tt.want {
+			t.Errorf("ParseMoney(%q) = %d, want %d", tt.in, m.Amount(), tt.want)
+		}
+	}
+
+	if _, err := ParseMoney("1", Currency("XXX")); err == nil {
+		t.Error("expected error for unsupported currency")
+	}
+}
+
+func TestMoneyArithmetic(t *testing.T) {
+	a := MustParseMoney("10.50", CZK)
+	b := MustParseMoney("0.75", CZK)
+
+	sum, err := a.Add(b)
+	if err != nil || sum.String() != "11.25 CZK" {
+		t.Errorf("Add = %v, %v", sum, err)
+	}
+
+	diff, err := b.Sub(a)
+	if err != nil || diff.Decimal() != "-9.75" {
+		t.Errorf("Sub = %v, %v", diff, err)
+	}
+
+	mul, err := b.Mul(3)
+	if err != nil || mul.Amount() != 225 {
+		t.Errorf("Mul = %v, %v", mul, err)
+	}
+
+	if _, err := a.Add(MustParseMoney("1", EUR)); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("expected ErrCurrencyMismatch, got %v", err)
+	}
+
+	big, _ := NewMoney(Amount(1<<62), CZK)
+	if _, err := big.Mul(4); !errors.Is(err, ErrAmountOverflow) {
+		t.Errorf("expected ErrAmountOverflow, got %v", err)
+	}
+}
+
+func TestMoneyJSON(t *testing.T) {
+	p := Payment{}
+	p.SetMoney(MustParseMoney("199.90", EUR))
+
+	data, err := json.Marshal(struct {
+		Amount   Amount   `json:"amount"`
+		Currency Currency `json:"currency"`
+	}{p.Amount, p.Currency})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	moneyData, err := json.Marshal(MustParseMoney("199.90", EUR))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(data) != string(moneyData) || string(data) != `{"amount":19990,"currency":"EUR"}` {
+		t.Errorf("unexpected JSON %s / %s", data, moneyData)
+	}
+
+	var m Money
+	if err := json.Unmarshal(moneyData, &m); err != nil {
+		t.Fatal(err)
+	}
+	if !m.Equal(MustParseMoney("199.90", EUR)) {
+		t.Errorf("round trip mismatch: %v", m)
+	}
+
+	if err := json.Unmarshal([]byte(`{"amount":1,"currency":"XXX"}`), &m); err == nil {
+		t.Error("expected error for unsupported currency")
+	}
+}
@@ -2,41 +2,76 @@ package payment
 
 type Payment struct {
 	Payer            *Payer    `json:"payer"`
-	Amount           int       `json:"amount"`
-	Currency         string    `json:"currency"`
+	Amount           Amount    `json:"amount"`
+	Currency         Currency  `json:"currency"`
 	OrderNumber      string    `json:"order_number"`
 	OrderDescription string    `json:"order_description"`
 	Items            []Item    `json:"items,omitempty"`
 	EshopId          int64     `json:"eshop_id,omitempty"`
 	Callback         *Callback `json:"callback"`
 	Lang             string    `json:"lang,omitempty"`
+
+	AdditionalParams []AdditionalParam `json:"additional_params,omitempty"`
 }
 
 type Payer struct {
-	AllowedPaymentInstruments []string `json:"allowed_payment_instruments,omitempty"`
+	AllowedPaymentInstruments []PaymentInstrument `json:"allowed_payment_instruments,omitempty"`
+	DefaultPaymentInstrument  PaymentInstrument   `json:"default_payment_instrument,omitempty"`
+	AllowedSwifts             []Swift             `json:"allowed_swifts,omitempty"`
+	DefaultSwift              Swift               `json:"default_swift,omitempty"`
+	Contact                   *Contact            `json:"contact,omitempty"`
+}
+
+// Contact prefills the customer details on the gateway form.
+type Contact struct {
+	FirstName   string `json:"first_name,omitempty"`
+	LastName    string `json:"last_name,omitempty"`
+	Email       string `json:"email,omitempty"`
+	PhoneNumber string `json:"phone_number,omitempty"`
+	City        string `json:"city,omitempty"`
+	Street      string `json:"street,omitempty"`
+	PostalCode  string `json:"postal_code,omitempty"`
+	// CountryCode is an ISO 3166-1 alpha-3 code, e.g. "CZE".
+	CountryCode string `json:"country_code,omitempty"`
 }
 
+// ItemType distinguishes goods from delivery and discounts in the order.
+type ItemType string
+
+const (
+	ItemTypeItem     ItemType = "ITEM"
+	ItemTypeDelivery ItemType = "DELIVERY"
+	ItemTypeDiscount ItemType = "DISCOUNT"
+)
+
 type Item struct {
-	Name    string `json:"name"`
-	Amount  int    `json:"amount"`
-	Count   int    `json:"count"`
-	VatRate int    `json:"vat_rate,omitempty"`
+	Type       ItemType `json:"type,omitempty"`
+	Name       string   `json:"name"`
+	Amount     Amount   `json:"amount"`
+	Count      int      `json:"count"`
+	VatRate    int      `json:"vat_rate,omitempty"`
+	ProductURL string   `json:"product_url,omitempty"`
+	EAN        string   `json:"ean,omitempty"`
+}
+
+// AdditionalParam is a free name/value pair stored with the payment and returned in its status.
+type AdditionalParam struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
 }
 
 type Callback struct {
-	Url          string `json:"url"`
-	Notification string `json:"notification"`
-}
-
-type PaymentResponse struct {
-	Id                int64     `json:"id"`
-	OrderNumber       string    `json:"order_number"`
-	State             string    `json:"state"`
-	Amount            int       `json:"amount"`
-	Currency          string    `json:"currency"`
-	Payer             *Payer    `json:"payer"`
-	EshopId           int64     `json:"eshop_id"`
-	Callback          *Callback `json:"callback"`
-	PaymentInstrument string    `json:"payment_instrument"`
-	GatewayURL        string    `json:"gateway_url"`
+	Url          string `json:"return_url"`
+	Notification string `json:"notification_url"`
+}
+
+// Money returns the payment amount together with its currency.
+func (p *Payment) Money() (Money, error) {
+	return NewMoney(p.Amount, p.Currency)
+}
+
+// SetMoney sets both the amount and the currency of the payment.
+func (p *Payment) SetMoney(m Money) {
+	p.Amount = m.Amount()
+	p.Currency = m.Currency()
 }
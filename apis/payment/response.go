@@ -0,0 +1,145 @@
+package payment
+
+// PaymentState is the state of a payment on the gateway.
+type PaymentState string
+
+const (
+	StateCreated             PaymentState = "CREATED"
+	StatePaymentMethodChosen PaymentState = "PAYMENT_METHOD_CHOSEN"
+	StatePaid                PaymentState = "PAID"
+	StateAuthorized          PaymentState = "AUTHORIZED"
+	StateCanceled            PaymentState = "CANCELED"
+	StateTimeouted           PaymentState = "TIMEOUTED"
+	StateRefunded            PaymentState = "REFUNDED"
+	StatePartiallyRefunded   PaymentState = "PARTIALLY_REFUNDED"
+)
+
+// IsFinal reports whether the payer has finished with the payment. AUTHORIZED is
+// considered final as well, the remaining capture or void is up to the e-shop.
+func (s PaymentState) IsFinal() bool {
+	switch s {
+	case StatePaid, StateAuthorized, StateCanceled, StateTimeouted, StateRefunded, StatePartiallyRefunded:
+		return true
+	}
+	return false
+}
+
+// SubState gives details about a pending or failed payment, e.g. "_101".
+type SubState string
+
+const (
+	// Payment pending, waiting for offline payment
+	SubStatePendingOffline SubState = "_101"
+	// Payment pending, waiting for online payment
+	SubStatePendingOnline SubState = "_102"
+	// Payment rejected by the payer's bank
+	SubStateRejectedByBank SubState = "_3001"
+	// Payment rejected by the card issuer
+	SubStateRejectedByIssuer SubState = "_3002"
+	// Payment rejected by the 3D Secure verification
+	SubStateRejected3DS SubState = "_3003"
+	// Payment rejected, insufficient funds
+	SubStateInsufficientFunds SubState = "_3004"
+	// Payment canceled by the payer
+	SubStateCanceledByPayer SubState = "_5002"
+)
+
+type PaymentResponse struct {
+	Id                int64             `json:"id"`
+	OrderNumber       string            `json:"order_number"`
+	State             PaymentState      `json:"state"`
+	SubState          SubState          `json:"sub_state,omitempty"`
+	Amount            Amount            `json:"amount"`
+	Currency          Currency          `json:"currency"`
+	Payer             *PayerDetails     `json:"payer"`
+	Target            *Target           `json:"target,omitempty"`
+	EshopId           int64             `json:"eshop_id,omitempty"`
+	Callback          *Callback         `json:"callback,omitempty"`
+	PaymentInstrument PaymentInstrument `json:"payment_instrument"`
+	GatewayURL        string            `json:"gw_url"`
+	Lang              string            `json:"lang,omitempty"`
+	Recurrence        *Recurrence       `json:"recurrence,omitempty"`
+	Preauthorization  *Preauthorization `json:"preauthorization,omitempty"`
+	EetCode           *EetCode          `json:"eet_code,omitempty"`
+
+	Items            []Item            `json:"items,omitempty"`
+	AdditionalParams []AdditionalParam `json:"additional_params,omitempty"`
+}
+
+// Money returns the paid amount together with its currency.
+func (p *PaymentResponse) Money() (Money, error) {
+	return NewMoney(p.Amount, p.Currency)
+}
+
+// PayerDetails is the payer block of a payment status. Besides the values sent on
+// creation it holds the card or bank account the payer used.
+type PayerDetails struct {
+	Payer
+
+	PaymentCard *CardDetails        `json:"payment_card,omitempty"`
+	BankAccount *BankAccountDetails `json:"bank_account,omitempty"`
+}
+
+// CardDetails describes the card used for the payment. The card number is masked by GoPay.
+type CardDetails struct {
+	CardNumber        string `json:"card_number,omitempty"`
+	CardExpiration    string `json:"card_expiration,omitempty"`
+	CardBrand         string `json:"card_brand,omitempty"`
+	CardIssuerCountry string `json:"card_issuer_country,omitempty"`
+	CardIssuerBank    string `json:"card_issuer_bank,omitempty"`
+	CardFingerprint   string `json:"card_fingerprint,omitempty"`
+	CardToken         string `json:"card_token,omitempty"`
+	ThreeDSResult     string `json:"3ds_result,omitempty"`
+}
+
+// BankAccountDetails describes the payer's account for bank transfers.
+type BankAccountDetails struct {
+	Prefix        string `json:"prefix,omitempty"`
+	AccountNumber string `json:"account_number,omitempty"`
+	BankCode      string `json:"bank_code,omitempty"`
+	AccountName   string `json:"account_name,omitempty"`
+	IBAN          string `json:"iban,omitempty"`
+	BIC           string `json:"bic,omitempty"`
+}
+
+// Target identifies the e-shop receiving the payment.
+type Target struct {
+	Type string `json:"type"`
+	GoId int64  `json:"goid"`
+}
+
+// Recurrence holds the recurring payment settings.
+type Recurrence struct {
+	RecurrenceCycle  string `json:"recurrence_cycle"`
+	RecurrencePeriod int    `json:"recurrence_period,omitempty"`
+	RecurrenceDateTo string `json:"recurrence_date_to,omitempty"`
+	RecurrenceState  string `json:"recurrence_state,omitempty"`
+}
+
+// Preauthorization holds the state of a card pre-authorization.
+type Preauthorization struct {
+	Requested bool   `json:"requested"`
+	State     string `json:"state,omitempty"`
+}
+
+// EetCode holds the codes of the Czech electronic sales records.
+type EetCode struct {
+	Fik string `json:"fik,omitempty"`
+	Bkp string `json:"bkp,omitempty"`
+	Pkp string `json:"pkp,omitempty"`
+}
+
+// OperationResult is the outcome of an operation on an existing payment.
+type OperationResult string
+
+const (
+	ResultAccepted OperationResult = "ACCEPTED"
+	ResultFinished OperationResult = "FINISHED"
+	ResultFailed   OperationResult = "FAILED"
+)
+
+// OperationResponse is returned by refund, capture and void operations.
+type OperationResponse struct {
+	Id     int64           `json:"id"`
+	Result OperationResult `json:"result"`
+}
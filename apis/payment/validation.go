@@ -0,0 +1,277 @@
+package payment
+
+import (
+	"fmt"
+	"net/mail"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	MaxOrderNumberLength      = 128
+	MaxOrderDescriptionLength = 256
+	MaxItemNameLength         = 256
+)
+
+// instrumentCurrencies restricts payment instruments that are available only for some currencies.
+// Instruments not listed here are accepted for every supported currency.
+var instrumentCurrencies = map[PaymentInstrument][]Currency{
+	MPayment:   {CZK},
+	PremiumSMS: {CZK},
+	Twisto:     {CZK},
+	SkipPay:    {CZK},
+	Bitcoin:    {CZK, EUR},
+}
+
+// FieldError describes a single invalid field. Field is the JSON path of the
+// field, e.g. "items[2].vat_rate".
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+func (e *FieldError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+// ValidationError is returned by Validate and holds every problem found in the model.
+type ValidationError struct {
+	Errors []*FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, 0, len(e.Errors))
+	for _, fe := range e.Errors {
+		msgs = append(msgs, fe.Error())
+	}
+	return "invalid payment: " + strings.Join(msgs, "; ")
+}
+
+// Unwrap exposes the individual field errors to errors.Is and errors.As.
+func (e *ValidationError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Errors))
+	for _, fe := range e.Errors {
+		errs = append(errs, fe)
+	}
+	return errs
+}
+
+// Field returns the error for the given field path, or nil when the field is valid.
+func (e *ValidationError) Field(path string) *FieldError {
+	for _, fe := range e.Errors {
+		if fe.Field == path {
+			return fe
+		}
+	}
+	return nil
+}
+
+type validator struct {
+	errs []*FieldError
+}
+
+func (v *validator) add(field, format string, args ...any) {
+	v.errs = append(v.errs, &FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) err() error {
+	if len(v.errs) == 0 {
+		return nil
+	}
+	return &ValidationError{Errors: v.errs}
+}
+
+// Validate checks the payment against the rules enforced by GoPay so that malformed
+// requests fail before they are sent. It returns a *ValidationError listing all problems.
+func (p *Payment) Validate() error {
+	v := &validator{}
+
+	if p.Amount <= 0 {
+		v.add("amount", "must be greater than zero")
+	}
+	if !p.Currency.Valid() {
+		v.add("currency", "unsupported currency %q", p.Currency)
+	}
+
+	switch n := utf8.RuneCountInString(p.OrderNumber); {
+	case n == 0:
+		v.add("order_number", "is required")
+	case n > MaxOrderNumberLength:
+		v.add("order_number", "must be at most %d characters long", MaxOrderNumberLength)
+	}
+	if utf8.RuneCountInString(p.OrderDescription) > MaxOrderDescriptionLength {
+		v.add("order_description", "must be at most %d characters long", MaxOrderDescriptionLength)
+	}
+
+	if len(p.Items) > 0 {
+		var sum Amount
+		for i := range p.Items {
+			p.Items[i].validate(v, fmt.Sprintf("items[%d]", i))
+			sum += p.Items[i].Amount
+		}
+		if sum != p.Amount {
+			v.add("items", "sum of item amounts %d does not match amount %d", sum, p.Amount)
+		}
+	}
+
+	for i, param := range p.AdditionalParams {
+		if param.Name == "" {
+			v.add(fmt.Sprintf("additional_params[%d].name", i), "is required")
+		}
+	}
+
+	if p.Payer != nil {
+		p.Payer.validate(v, "payer", p.Currency)
+	}
+
+	if p.Callback == nil {
+		v.add("callback", "is required")
+	} else {
+		p.Callback.validate(v, "callback")
+	}
+
+	return v.err()
+}
+
+func (i *Item) validate(v *validator, path string) {
+	switch n := utf8.RuneCountInString(i.Name); {
+	case n == 0:
+		v.add(path+".name", "is required")
+	case n > MaxItemNameLength:
+		v.add(path+".name", "must be at most %d characters long", MaxItemNameLength)
+	}
+	if i.Count < 0 {
+		v.add(path+".count", "must not be negative")
+	}
+	// The rates differ by country and change over time, GoPay checks the rate itself.
+	if i.VatRate < 0 || i.VatRate > 100 {
+		v.add(path+".vat_rate", "must be between 0 and 100")
+	}
+
+	switch i.Type {
+	case "", ItemTypeItem, ItemTypeDelivery:
+		if i.Amount < 0 {
+			v.add(path+".amount", "must not be negative")
+		}
+	case ItemTypeDiscount:
+		if i.Amount > 0 {
+			v.add(path+".amount", "discount must not be positive")
+		}
+	default:
+		v.add(path+".type", "unsupported item type %q", i.Type)
+	}
+
+	if i.ProductURL != "" {
+		if u, err := url.Parse(i.ProductURL); err != nil || !u.IsAbs() || u.Host == "" {
+			v.add(path+".product_url", "must be an absolute URL")
+		}
+	}
+	if i.EAN != "" && !validEAN(i.EAN) {
+		v.add(path+".ean", "invalid EAN %q", i.EAN)
+	}
+}
+
+// validEAN checks length and check digit of EAN-8, UPC-A, EAN-13 and GTIN-14 codes.
+func validEAN(code string) bool {
+	switch len(code) {
+	case 8, 12, 13, 14:
+	default:
+		return false
+	}
+	if !isDigits(code) {
+		return false
+	}
+
+	sum := 0
+	for i := len(code) - 2; i >= 0; i-- {
+		d := int(code[i] - '0')
+		if (len(code)-2-i)%2 == 0 {
+			d *= 3
+		}
+		sum += d
+	}
+	return (10-sum%10)%10 == int(code[len(code)-1]-'0')
+}
+
+func (p *Payer) validate(v *validator, path string, currency Currency) {
+	for i, instrument := range p.AllowedPaymentInstruments {
+		allowed, ok := instrumentCurrencies[instrument]
+		if !ok || !currency.Valid() {
+			continue
+		}
+		if !contains(allowed, currency) {
+			v.add(fmt.Sprintf("%s.allowed_payment_instruments[%d]", path, i), "%s is not available for %s", instrument, currency)
+		}
+	}
+
+	if p.DefaultPaymentInstrument != "" && len(p.AllowedPaymentInstruments) > 0 &&
+		!contains(p.AllowedPaymentInstruments, p.DefaultPaymentInstrument) {
+		v.add(path+".default_payment_instrument", "%s is not among allowed payment instruments", p.DefaultPaymentInstrument)
+	}
+
+	if len(p.AllowedSwifts) > 0 || p.DefaultSwift != "" {
+		if len(p.AllowedPaymentInstruments) > 0 && !contains(p.AllowedPaymentInstruments, BankAccount) {
+			v.add(path+".allowed_swifts", "requires %s among allowed payment instruments", BankAccount)
+		}
+	}
+	if p.DefaultSwift != "" && len(p.AllowedSwifts) > 0 && !contains(p.AllowedSwifts, p.DefaultSwift) {
+		v.add(path+".default_swift", "%s is not among allowed swifts", p.DefaultSwift)
+	}
+
+	if p.Contact != nil {
+		p.Contact.validate(v, path+".contact")
+	}
+}
+
+func (c *Contact) validate(v *validator, path string) {
+	if c.Email != "" {
+		if addr, err := mail.ParseAddress(c.Email); err != nil || addr.Address != c.Email {
+			v.add(path+".email", "invalid e-mail address")
+		}
+	}
+	if c.CountryCode != "" && !isUpperAlpha(c.CountryCode, 3) {
+		v.add(path+".country_code", "must be an ISO 3166-1 alpha-3 code")
+	}
+}
+
+func isUpperAlpha(s string, length int) bool {
+	if len(s) != length {
+		return false
+	}
+	for _, r := range s {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+func contains[T comparable](list []T, item T) bool {
+	for _, i := range list {
+		if i == item {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Callback) validate(v *validator, path string) {
+	validateHTTPSURL(v, path+".return_url", c.Url)
+	validateHTTPSURL(v, path+".notification_url", c.Notification)
+}
+
+func validateHTTPSURL(v *validator, field, raw string) {
+	if raw == "" {
+		v.add(field, "is required")
+		return
+	}
+	u, err := url.Parse(raw)
+	if err != nil || !u.IsAbs() || u.Host == "" {
+		v.add(field, "must be an absolute URL")
+		return
+	}
+	if u.Scheme != "https" {
+		v.add(field, "must use https")
+	}
+}
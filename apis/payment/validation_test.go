@@ -0,0 +1,122 @@
+package payment
+
+import (
+	"errors"
+	"testing"
+)
+
+func validPayment() *Payment {
+	return &Payment{
+		Amount:      1500,
+		Currency:    CZK,
+		OrderNumber: "2024-0001",
+		Items: []Item{
+			{Name: "Book", Amount: 1200, Count: 1, VatRate: 12},
+			{Name: "Delivery", Amount: 300, Count: 1, VatRate: 21},
+		},
+		Callback: &Callback{
+			Url:          "https://eshop.example/return",
+			Notification: "https://eshop.example/notify",
+		},
+	}
+}
+
+func TestPaymentValidate(t *testing.T) {
+	if err := validPayment().Validate(); err != nil {
+		t.Fatalf("expected valid payment, got %v", err)
+	}
+
+	p := validPayment()
+	p.Amount = 1600
+	p.Items[1].VatRate = -1
+	p.Callback.Notification = "http://eshop.example/notify"
+	p.Callback.Url = "/return"
+	p.Payer = &Payer{AllowedPaymentInstruments: []PaymentInstrument{PaymentCard, PremiumSMS}}
+	p.Currency = EUR
+
+	err := p.Validate()
+
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("expected *ValidationError, got %v", err)
+	}
+
+	for _, field := range []string{
+		"items",
+		"items[1].vat_rate",
+		"callback.return_url",
+		"callback.notification_url",
+		"payer.allowed_payment_instruments[1]",
+	} {
+		if verr.Field(field) == nil {
+			t.Errorf("expected error for %s in %v", field, verr)
+		}
+	}
+
+	if len(verr.Errors) != 5 {
+		t.Errorf("expected 5 errors, got %d: %v", len(verr.Errors), verr)
+	}
+
+	var fe *FieldError
+	if !errors.As(err, &fe) {
+		t.Error("expected errors.As to find a *FieldError")
+	}
+}
+
+func TestItemVatRateOfOtherCountries(t *testing.T) {
+	p := validPayment()
+	p.Currency = EUR
+	p.Items[0].VatRate = 23
+	p.Items[1].VatRate = 20
+
+	if err := p.Validate(); err != nil {
+		t.Errorf("expected Slovak VAT rates to pass, got %v", err)
+	}
+}
+
+func TestPayerValidate(t *testing.T) {
+	p := validPayment()
+	p.Payer = &Payer{
+		AllowedPaymentInstruments: []PaymentInstrument{PaymentCard},
+		DefaultPaymentInstrument:  BankAccount,
+		AllowedSwifts:             []Swift{SwiftFioBanka},
+		DefaultSwift:              SwiftAirBank,
+		Contact: &Contact{
+			Email:       "not an email",
+			CountryCode: "CZ",
+		},
+	}
+
+	var verr *ValidationError
+	if !errors.As(p.Validate(), &verr) {
+		t.Fatal("expected *ValidationError")
+	}
+
+	for _, field := range []string{
+		"payer.default_payment_instrument",
+		"payer.allowed_swifts",
+		"payer.default_swift",
+		"payer.contact.email",
+		"payer.contact.country_code",
+	} {
+		if verr.Field(field) == nil {
+			t.Errorf("expected error for %s in %v", field, verr)
+		}
+	}
+
+	p.Payer = &Payer{
+		AllowedPaymentInstruments: []PaymentInstrument{PaymentCard, BankAccount},
+		DefaultPaymentInstrument:  BankAccount,
+		AllowedSwifts:             []Swift{SwiftFioBanka, SwiftAirBank},
+		DefaultSwift:              SwiftAirBank,
+		Contact: &Contact{
+			FirstName:   "Jan",
+			LastName:    "Novák",
+			Email:       "jan.novak@example.com",
+			CountryCode: "CZE",
+		},
+	}
+	if err := p.Validate(); err != nil {
+		t.Errorf("expected valid payer, got %v", err)
+	}
+}
@@ -0,0 +1,87 @@
+package gopay
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/tkliner/go-gopay/client/auth"
+	"github.com/tkliner/go-gopay/client/config"
+)
+
+type staticAuthenticator struct {
+	closed bool
+}
+
+func (a *staticAuthenticator) GetAccessToken(context.Context) (string, error) {
+	return "custom-token", nil
+}
+
+func (a *staticAuthenticator) Status() (auth.TokenStatus, error) {
+	return auth.TokenStatus{Valid: true, ExpiresAt: time.Now().Add(time.Hour)}, nil
+}
+
+func (a *staticAuthenticator) Close() {
+	a.closed = true
+}
+
+func TestWithAuthenticator(t *testing.T) {
+	var authorization string
+	srv := newTestGateway(t, func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(paymentStatusJSON))
+	})
+
+	a := &staticAuthenticator{}
+	c, err := New(config.NewConfig(
+		config.WithGatewayURL(srv.URL),
+		config.WithAuthenticator(a),
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := c.Payment().GetPayment(context.Background(), 3000006529); err != nil {
+		t.Fatalf("GetPayment: %v", err)
+	}
+	if authorization != "Bearer custom-token" {
+		t.Errorf("unexpected authorization %q", authorization)
+	}
+	if c.Authenticator() != a {
+		t.Error("Authenticator does not return the configured one")
+	}
+
+	if err := c.Close(context.Background()); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if a.closed {
+		t.Error("configured authenticator was closed by the client")
+	}
+}
+
+type tokenFunc func(context.Context) (string, error)
+
+func (f tokenFunc) GetAccessToken(ctx context.Context) (string, error) {
+	return f(ctx)
+}
+
+func TestWithAuthenticatorTokenSource(t *testing.T) {
+	srv := newTestGateway(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(paymentStatusJSON))
+	})
+
+	c := newTestClient(t, srv, config.WithAuthenticator(tokenFunc(func(context.Context) (string, error) {
+		return "custom-token", nil
+	})))
+
+	if _, err := c.Payment().GetPayment(context.Background(), 3000006529); err != nil {
+		t.Fatalf("GetPayment: %v", err)
+	}
+	if _, err := c.Authenticator().Status(); !errors.Is(err, auth.ErrNoTokenStatus) {
+		t.Errorf("expected ErrNoTokenStatus, got %v", err)
+	}
+}
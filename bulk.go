@@ -0,0 +1,104 @@
+package gopay
+
+import (
+	"context"
+	"sync"
+
+	paymentApi "github.com/tkliner/go-gopay/apis/payment"
+)
+
+const defaultBulkConcurrency = 8
+
+// BulkOptions configures GetPayments.
+type BulkOptions struct {
+	// Concurrency is the number of parallel lookups (8 by default). Combine it with
+	// config.WithRateLimits to stay within the GoPay throttling limits.
+	Concurrency int
+	// OnProgress is called after every finished lookup with the number of finished
+	// lookups and the number of unique IDs. Calls are serialized.
+	OnProgress func(done, total int)
+	// CallOptions are applied to every lookup.
+	CallOptions []CallOption
+}
+
+// BulkResult is the outcome of one lookup of GetPayments.
+type BulkResult struct {
+	Id      int64
+	Payment *paymentApi.PaymentResponse
+	Err     error
+}
+
+// GetPayments looks up the status of many payments using a bounded pool of workers.
+// Duplicate IDs are looked up once. Results are streamed in completion order and a
+// failed lookup is reported in its BulkResult without affecting the others.
+//
+// The channel is closed when all lookups finished or ctx is done; lookups not
+// reported before cancellation are dropped. The caller must either drain the
+// channel or cancel ctx, after which it may stop reading.
+func (p *payment) GetPayments(ctx context.Context, ids []int64, opts BulkOptions) <-chan BulkResult {
+	unique := make([]int64, 0, len(ids))
+	seen := make(map[int64]bool, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+
+	workers := opts.Concurrency
+	if workers <= 0 {
+		workers = defaultBulkConcurrency
+	}
+	if workers > len(unique) {
+		workers = len(unique)
+	}
+
+	jobs := make(chan int64)
+	results := make(chan BulkResult, workers)
+
+	var (
+		wg         sync.WaitGroup
+		progressMu sync.Mutex
+		done       int
+	)
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for id := range jobs {
+				resp, err := p.GetPayment(ctx, id, opts.CallOptions...)
+				select {
+				case results <- BulkResult{Id: id, Payment: resp, Err: err}:
+				case <-ctx.Done():
+					return
+				}
+
+				if opts.OnProgress != nil {
+					progressMu.Lock()
+					done++
+					opts.OnProgress(done, len(unique))
+					progressMu.Unlock()
+				}
+			}
+		}()
+	}
+
+	go func() {
+		defer close(jobs)
+		for _, id := range unique {
+			select {
+			case jobs <- id:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	return results
+}
@@ -0,0 +1,93 @@
+package gopay
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGetPayments(t *testing.T) {
+	var inFlight, maxInFlight, requests atomic.Int32
+
+	srv := newTestGateway(t, func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			m := maxInFlight.Load()
+			if n <= m || maxInFlight.CompareAndSwap(m, n) {
+				break
+			}
+		}
+
+		id := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+		if id == "13" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"id": %s, "state": "PAID"}`, id)
+	})
+
+	ids := []int64{}
+	for i := int64(1); i <= 20; i++ {
+		ids = append(ids, i, i)
+	}
+
+	var lastDone, lastTotal int
+	results := newTestClient(t, srv).Payment().GetPayments(context.Background(), ids, BulkOptions{
+		Concurrency: 3,
+		OnProgress: func(done, total int) {
+			lastDone, lastTotal = done, total
+		},
+	})
+
+	got := map[int64]BulkResult{}
+	for r := range results {
+		got[r.Id] = r
+	}
+
+	if len(got) != 20 || requests.Load() != 20 {
+		t.Errorf("expected 20 unique lookups, got %d results and %d requests", len(got), requests.Load())
+	}
+	if got[13].Err == nil || got[12].Err != nil || got[12].Payment.Id != 12 {
+		t.Errorf("unexpected results %+v / %+v", got[13], got[12])
+	}
+	if maxInFlight.Load() > 3 {
+		t.Errorf("concurrency limit exceeded: %d", maxInFlight.Load())
+	}
+	if lastDone != 20 || lastTotal != 20 {
+		t.Errorf("unexpected progress %d/%d", lastDone, lastTotal)
+	}
+}
+
+func TestGetPaymentsCancelAbandoned(t *testing.T) {
+	var requests atomic.Int32
+	srv := newTestGateway(t, func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id": 1, "state": "PAID"}`))
+	})
+
+	ids := []int64{}
+	for i := int64(1); i <= 50; i++ {
+		ids = append(ids, i)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	newTestClient(t, srv).Payment().GetPayments(ctx, ids, BulkOptions{Concurrency: 4})
+
+	// Nobody reads the results: the buffer fills up and every worker blocks on
+	// its next result before ctx is cancelled.
+	for requests.Load() < 8 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+
+	verifyNoGoroutines(t, "(*payment).GetPayments")
+}
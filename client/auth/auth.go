@@ -2,16 +2,49 @@ package auth
 
 import (
 	"context"
+	"errors"
 	"time"
+
+	"github.com/tkliner/go-gopay/client/config"
 )
 
 // Authenticator defines an interface for obtaining and managing access tokens.
 // Implementations should provide a method to retrieve an access token, as well as
 // a method to check the current authentication status and token expiration time.
 //
-// The Status() method returns the current authentication status as a string,
-// the token's expiration time as a time.Time, and an error if applicable.
+// The Status() method describes the cached token without returning the token itself.
 type Authenticator interface {
 	GetAccessToken(ctx context.Context) (string, error)
-	Status() (string, time.Time, error)
+	Status() (TokenStatus, error)
+}
+
+// TokenStatus describes an access token without revealing it.
+type TokenStatus struct {
+	Scope config.TokenScope
+	// Valid is false when no token is cached or it has expired.
+	Valid     bool
+	ExpiresAt time.Time
+	// Fingerprint is a short hash of the token to match it in gateway logs.
+	Fingerprint string
+}
+
+// ErrNoTokenStatus is returned by Status of a token source that does not describe
+// its tokens, see FromTokenSource.
+var ErrNoTokenStatus = errors.New("token status is not available")
+
+// FromTokenSource returns the token source set by config.WithAuthenticator as an
+// Authenticator. A source that does not implement Status reports ErrNoTokenStatus.
+func FromTokenSource(src config.TokenSource) Authenticator {
+	if a, ok := src.(Authenticator); ok {
+		return a
+	}
+	return tokenSource{src}
+}
+
+type tokenSource struct {
+	config.TokenSource
+}
+
+func (tokenSource) Status() (TokenStatus, error) {
+	return TokenStatus{}, ErrNoTokenStatus
 }
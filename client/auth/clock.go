@@ -0,0 +1,25 @@
+package auth
+
+import "time"
+
+// clock is the time source of the token expiry and the auto refresh. Tests replace it
+// to control the refresh schedule.
+var clock timeSource = systemClock{}
+
+type timeSource interface {
+	Now() time.Time
+	// NewTimer returns a channel receiving the time once d has passed, and a function
+	// stopping the timer like time.Timer.Stop.
+	NewTimer(d time.Duration) (<-chan time.Time, func() bool)
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) NewTimer(d time.Duration) (<-chan time.Time, func() bool) {
+	t := time.NewTimer(d)
+	return t.C, t.Stop
+}
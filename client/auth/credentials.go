@@ -0,0 +1,67 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/tkliner/go-gopay/client/config"
+)
+
+// StaticCredentials returns the same credentials on every call.
+type StaticCredentials config.Credentials
+
+func (s StaticCredentials) Credentials(context.Context) (config.Credentials, error) {
+	return config.Credentials(s), nil
+}
+
+// FileCredentialsProvider reads the credentials from a JSON file with client_id
+// and client_secret, e.g. one rendered by a vault agent. The file is read again
+// whenever its modification time changes.
+type FileCredentialsProvider struct {
+	path string
+
+	mu      sync.Mutex
+	modTime time.Time
+	creds   config.Credentials
+}
+
+func NewFileCredentialsProvider(path string) *FileCredentialsProvider {
+	return &FileCredentialsProvider{path: path}
+}
+
+func (p *FileCredentialsProvider) Credentials(context.Context) (config.Credentials, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	info, err := os.Stat(p.path)
+	if err != nil {
+		return config.Credentials{}, fmt.Errorf("failed to read credentials: %w", err)
+	}
+	if info.ModTime().Equal(p.modTime) {
+		return p.creds, nil
+	}
+
+	data, err := os.ReadFile(p.path)
+	if err != nil {
+		return config.Credentials{}, fmt.Errorf("failed to read credentials: %w", err)
+	}
+
+	var file struct {
+		ClientId     string `json:"client_id"`
+		ClientSecret string `json:"client_secret"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return config.Credentials{}, fmt.Errorf("failed to parse credentials %s: %w", p.path, err)
+	}
+	if file.ClientId == "" || file.ClientSecret == "" {
+		return config.Credentials{}, fmt.Errorf("credentials %s miss client_id or client_secret", p.path)
+	}
+
+	p.creds = config.Credentials{ClientId: file.ClientId, ClientSecret: file.ClientSecret}
+	p.modTime = info.ModTime()
+	return p.creds, nil
+}
@@ -0,0 +1,83 @@
+package auth
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tkliner/go-gopay/client/config"
+	"github.com/tkliner/go-gopay/client/logger"
+	"github.com/tkliner/go-gopay/client/storage/inmemory"
+)
+
+func TestFileCredentialsRotation(t *testing.T) {
+	var used []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		basic, _ := base64.StdEncoding.DecodeString(strings.TrimPrefix(r.Header.Get("Authorization"), "Basic "))
+		used = append(used, string(basic))
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"token_type":"bearer","access_token":"token","expires_in":0}`))
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "gopay.json")
+	writeCredentials := func(secret string, modTime time.Time) {
+		os.WriteFile(path, []byte(`{"client_id":"client","client_secret":"`+secret+`"}`), 0o600)
+		os.Chtimes(path, modTime, modTime)
+	}
+	writeCredentials("old", time.Now().Add(-time.Hour))
+
+	cfg := config.NewConfig(
+		config.WithGatewayURL(srv.URL),
+		config.WithCredentialsProvider(NewFileCredentialsProvider(path)),
+	)
+	a := NewGopayAuthenticator(inmemory.NewInMemoryTokenStorage(), srv.Client(), cfg, logger.NewNoOpLogger())
+	defer a.Close()
+
+	if _, err := a.GetAccessToken(context.Background()); err != nil {
+		t.Fatalf("GetAccessToken: %v", err)
+	}
+	writeCredentials("new", time.Now())
+	if _, err := a.GetAccessToken(context.Background()); err != nil {
+		t.Fatalf("GetAccessToken: %v", err)
+	}
+
+	if len(used) != 2 || used[0] != "client:old" || used[1] != "client:new" {
+		t.Fatalf("unexpected credentials %v", used)
+	}
+}
+
+func TestFileCredentialsInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gopay.json")
+	os.WriteFile(path, []byte(`{"client_id":"client"}`), 0o600)
+
+	if _, err := NewFileCredentialsProvider(path).Credentials(context.Background()); err == nil {
+		t.Fatal("expected error for missing secret")
+	}
+}
+
+func TestStatusHidesToken(t *testing.T) {
+	ts := inmemory.NewInMemoryTokenStorage()
+	ts.SaveAccessToken("secret-token", time.Now().Add(time.Minute))
+
+	cfg := config.NewConfig(config.WithScope(config.TokenScopeCreatePayment))
+	a := NewGopayAuthenticator(ts, http.DefaultClient, cfg, logger.NewNoOpLogger())
+	defer a.Close()
+
+	status, err := a.Status()
+	if err != nil {
+		t.Fatalf("Status: %v", err)
+	}
+	if !status.Valid || status.Scope != config.TokenScopeCreatePayment {
+		t.Errorf("unexpected status %+v", status)
+	}
+	if status.Fingerprint != Fingerprint("secret-token") || strings.Contains(status.Fingerprint, "secret") {
+		t.Errorf("unexpected fingerprint %q", status.Fingerprint)
+	}
+}
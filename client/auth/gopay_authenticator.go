@@ -2,23 +2,29 @@ package auth
 
 import (
 	"context"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/tkliner/go-gopay/client/config"
 	"github.com/tkliner/go-gopay/client/logger"
 	"github.com/tkliner/go-gopay/client/storage"
+	"github.com/tkliner/go-gopay/client/storage/inmemory"
 )
 
 // RefreshInterval specifies the duration after which the authentication token should be refreshed.
-// It is set to 25 minutes to ensure tokens are renewed before expiration.
+//
+// Deprecated: the auto refresh is scheduled relative to the token expiry, see config.TokenRefresh.
 const (
 	RefreshInterval 	 = 25 * time.Minute
 
@@ -35,14 +41,30 @@ const (
 // and provides thread-safe access to authentication resources.
 // The struct uses a mutex for concurrency control, stores tokens via a
 // TokenStorage implementation, and supports context cancellation for request management.
+//
+// Tokens are cached per scope. The scope configured in Config is the broadest one
+// the authenticator may request and its token is kept in the TokenStorage; tokens
+// of narrower scopes requested by WithScope are kept in memory.
 type GopayAuthenticator struct {
 	mu           sync.Mutex
 	tokenStorage storage.TokenStorage
+	scoped       map[config.TokenScope]storage.TokenStorage
 	httpClient   *http.Client
 	cfg          *config.Config
 	logger       logger.Logger
 	ctx          context.Context
 	cancel       context.CancelFunc
+	refresh      sync.Once
+	wg           sync.WaitGroup
+	// fetches are the token requests in flight by scope. a.mu guards the map but is
+	// not held during the requests.
+	fetches map[config.TokenScope]*tokenFetch
+	// lastUsed is the time of the last token request in Unix nanoseconds.
+	lastUsed atomic.Int64
+	// wake reschedules the auto refresh after a new token was fetched or the
+	// client was used while the refresh was paused.
+	wake   chan struct{}
+	paused atomic.Bool
 }
 
 func NewGopayAuthenticator(
@@ -55,12 +77,16 @@ func NewGopayAuthenticator(
 
 	a := &GopayAuthenticator{
 		tokenStorage: ts,
+		scoped:       map[config.TokenScope]storage.TokenStorage{},
+		fetches:      map[config.TokenScope]*tokenFetch{},
 		httpClient:   httpClient,
 		cfg:          cfg,
 		logger:       logger,
 		ctx:          ctx,
 		cancel:       cancel,
+		wake:         make(chan struct{}, 1),
 	}
+	a.lastUsed.Store(clock.Now().UnixNano())
 
 	if cfg.AutoRefresh {
 		a.StartAutoRefresh()
@@ -69,27 +95,118 @@ func NewGopayAuthenticator(
 	return a
 }
 
+// GetAccessToken returns a valid token of the scope requested by WithScope, or of
+// the configured scope if the context requests none. A scope not covered by the
+// configured one fails with *ScopeError.
 func (a *GopayAuthenticator) GetAccessToken(ctx context.Context) (string, error) {
+	scope := a.grantedScope()
+	if required, ok := ScopeFromContext(ctx); ok {
+		if !scope.Covers(required) {
+			return "", &ScopeError{Required: required, Granted: scope}
+		}
+		scope = required
+	}
+
+	a.touch()
+
+	for {
+		a.mu.Lock()
+		token, expiresAt, err := a.storageFor(scope).GetAccessToken()
+		if err == nil && token != "" && clock.Now().Before(expiresAt) {
+			a.mu.Unlock()
+			a.logger.Info(ctx, "Access token fetched from cache", "scope", scope)
+			return token, nil
+		}
+		f, shared := a.startFetch(scope)
+		a.mu.Unlock()
+
+		if !shared {
+			a.logger.Info(ctx, "Access token expired or not found, requesting a new one", "scope", scope)
+			a.runFetch(ctx, scope, f)
+			return f.token, f.err
+		}
+
+		select {
+		case <-f.done:
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+		// A request ended by the context of the caller that sent it is repeated with ours.
+		if !errors.Is(f.err, context.Canceled) && !errors.Is(f.err, context.DeadlineExceeded) {
+			return f.token, f.err
+		}
+	}
+}
+
+// tokenFetch is a token request shared by the callers that need a token of its scope.
+type tokenFetch struct {
+	done      chan struct{}
+	token     string
+	expiresAt time.Time
+	err       error
+}
+
+// startFetch returns the token request of the scope in flight, or registers a new one
+// which the caller must send with runFetch. Callers of the same scope thus share one
+// request, while cached tokens and requests of other scopes are not held up by it.
+// It must be called with a.mu held.
+func (a *GopayAuthenticator) startFetch(scope config.TokenScope) (*tokenFetch, bool) {
+	if f, ok := a.fetches[scope]; ok {
+		return f, true
+	}
+	f := &tokenFetch{done: make(chan struct{})}
+	a.fetches[scope] = f
+	return f, false
+}
+
+// runFetch sends the token request registered by startFetch and saves the token.
+func (a *GopayAuthenticator) runFetch(ctx context.Context, scope config.TokenScope, f *tokenFetch) {
+	defer close(f.done)
+
+	f.token, f.expiresAt, f.err = a.requestNewAccessToken(ctx, scope)
+
 	a.mu.Lock()
-	defer a.mu.Unlock()
+	delete(a.fetches, scope)
+	if f.err == nil {
+		if err := a.storageFor(scope).SaveAccessToken(f.token, f.expiresAt); err != nil {
+			a.logger.Error(ctx, "Failed to save access token to storage", "error", err)
+		}
+	}
+	a.mu.Unlock()
 
-	token, expiresAt, err := a.tokenStorage.GetAccessToken()
-	if err == nil && time.Now().Before(expiresAt) {
-		a.logger.Info(ctx, "Access token fetched from cache")
-		return token, nil
+	if f.err == nil {
+		a.reschedule()
 	}
+}
 
-	a.logger.Info(ctx, "Access token expired or not found, requesting a new one")
-	token, expiresAt, err = a.requestNewAccessToken(ctx)
-	if err != nil {
-		return "", err
+// grantedScope returns the broadest scope the authenticator may request.
+func (a *GopayAuthenticator) grantedScope() config.TokenScope {
+	if a.cfg.Scope == "" {
+		return config.TokenScopeAll
 	}
+	return a.cfg.Scope
+}
 
-	if err := a.tokenStorage.SaveAccessToken(token, expiresAt); err != nil {
-		a.logger.Error(ctx, "Failed to save access token to storage", "error", err)
+// storageFor returns the storage of the scope tokens. It must be called with a.mu held.
+func (a *GopayAuthenticator) storageFor(scope config.TokenScope) storage.TokenStorage {
+	if scope == a.grantedScope() {
+		return a.tokenStorage
+	}
+	ts, ok := a.scoped[scope]
+	if !ok {
+		ts = inmemory.NewInMemoryTokenStorage()
+		a.scoped[scope] = ts
 	}
+	return ts
+}
 
-	return token, nil
+// scopes returns the scopes with a cached token. It must be called with a.mu held.
+func (a *GopayAuthenticator) scopes() []config.TokenScope {
+	scopes := []config.TokenScope{a.grantedScope()}
+	for scope := range a.scoped {
+		scopes = append(scopes, scope)
+	}
+	return scopes
 }
 
 // requestNewAccessToken requests a new access token from the GoPay authentication server using the client credentials
@@ -98,18 +215,24 @@ func (a *GopayAuthenticator) GetAccessToken(ctx context.Context) (string, error)
 //
 // Parameters:
 //   - ctx: The context for controlling cancellation and timeouts of the HTTP request.
+//   - scope: The scope of the requested token.
 //
 // Returns:
 //   - string: The newly obtained access token.
 //   - time.Time: The expiration time of the access token.
 //   - error: An error if the request fails, the response status is not OK, or the response cannot be parsed.
-func (a *GopayAuthenticator) requestNewAccessToken(ctx context.Context) (string, time.Time, error) {
-	authString := a.cfg.ClientId + ":" + a.cfg.ClientSecret
+func (a *GopayAuthenticator) requestNewAccessToken(ctx context.Context, scope config.TokenScope) (string, time.Time, error) {
+	creds, err := a.credentials(ctx)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	authString := creds.ClientId + ":" + creds.ClientSecret
 	encodedAuth := base64.StdEncoding.EncodeToString([]byte(authString))
 
 	form := url.Values{}
 	form.Set(grantTypeHeaderName, grandTypeheaderValue)
-	form.Set(scopeHeaderName, string(a.cfg.Scope))
+	form.Set(scopeHeaderName, string(scope))
 	form.Set(languageHeaderName, string(a.cfg.Language))
 	body := strings.NewReader(form.Encode())
 
@@ -132,7 +255,7 @@ func (a *GopayAuthenticator) requestNewAccessToken(ctx context.Context) (string,
 
 	if resp.StatusCode != http.StatusOK {
 		respBody, _ := io.ReadAll(resp.Body)
-		return "", time.Time{}, fmt.Errorf("unexpected status code: %d, response: %s", resp.StatusCode, string(respBody))
+		return "", time.Time{}, newTokenError(resp.StatusCode, respBody)
 	}
 
 	var tokenResp struct {
@@ -144,59 +267,97 @@ func (a *GopayAuthenticator) requestNewAccessToken(ctx context.Context) (string,
 		return "", time.Time{}, fmt.Errorf("failed to decode token response: %w", err)
 	}
 
-	expiresAt := time.Now().Add(time.Duration(tokenResp.ExpiresIn) * time.Second)
+	expiresAt := clock.Now().Add(time.Duration(tokenResp.ExpiresIn) * time.Second)
 	return tokenResp.AccessToken, expiresAt, nil
 }
 
-func (a *GopayAuthenticator) Status() (string, time.Time, error) {
-	a.mu.Lock()
-	defer a.mu.Unlock()
+// OAuth error codes of the token endpoint, see RFC 6749 section 5.2.
+const (
+	TokenErrorInvalidRequest     = "invalid_request"
+	TokenErrorInvalidClient      = "invalid_client"
+	TokenErrorInvalidGrant       = "invalid_grant"
+	TokenErrorUnauthorizedClient = "unauthorized_client"
+	TokenErrorInvalidScope       = "invalid_scope"
+)
 
-	token, expiresAt, err := a.tokenStorage.GetAccessToken()
-	if err != nil {
-		return "", time.Time{}, err
+// TokenError is returned when the token endpoint rejects the token request.
+type TokenError struct {
+	StatusCode int
+	Body       []byte
+	// Code is the OAuth error code of the response, e.g. TokenErrorInvalidScope,
+	// empty if the body is not an OAuth error response.
+	Code string
+	// Description is the error_description of the OAuth error response.
+	Description string
+}
+
+func newTokenError(statusCode int, body []byte) *TokenError {
+	var oauthErr struct {
+		Error       string `json:"error"`
+		Description string `json:"error_description"`
 	}
+	json.Unmarshal(body, &oauthErr)
 
-	return token, expiresAt, nil
+	return &TokenError{
+		StatusCode:  statusCode,
+		Body:        body,
+		Code:        oauthErr.Error,
+		Description: oauthErr.Description,
+	}
 }
 
-func (a *GopayAuthenticator) StartAutoRefresh() {
-	go func() {
-		ticker := time.NewTicker(RefreshInterval)
-		defer ticker.Stop()
+func (e *TokenError) Error() string {
+	return fmt.Sprintf("unexpected status code: %d, response: %s", e.StatusCode, string(e.Body))
+}
 
-		for {
-			select {
-			case <-ticker.C:
-				a.logger.Info(a.ctx, "Auto-refresh: Starting token renewal")
+// credentials returns the client credentials from the configured provider, or the
+// static ones from Config.
+func (a *GopayAuthenticator) credentials(ctx context.Context) (config.Credentials, error) {
+	if a.cfg.CredentialsProvider == nil {
+		return config.Credentials{ClientId: a.cfg.ClientId, ClientSecret: a.cfg.ClientSecret}, nil
+	}
 
-				a.mu.Lock()
+	creds, err := a.cfg.CredentialsProvider.Credentials(ctx)
+	if err != nil {
+		return config.Credentials{}, fmt.Errorf("failed to get client credentials: %w", err)
+	}
+	return creds, nil
+}
 
-				token, expiresAt, err := a.requestNewAccessToken(a.ctx)
-				if err != nil {
-					a.mu.Unlock()
-					a.logger.Error(a.ctx, "Auto-refresh: Failed to refresh token", "error", err)
-					continue
-				}
+// Status describes the cached token of the configured scope.
+func (a *GopayAuthenticator) Status() (TokenStatus, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
 
-				if err := a.tokenStorage.SaveAccessToken(token, expiresAt); err != nil {
-					a.logger.Error(a.ctx, "Auto-refresh: Failed to save refreshed token", "error", err)
-				}
+	scope := a.grantedScope()
+	token, expiresAt, err := a.tokenStorage.GetAccessToken()
+	if err != nil {
+		return TokenStatus{}, err
+	}
 
-				a.mu.Unlock()
+	status := TokenStatus{Scope: scope, ExpiresAt: expiresAt}
+	if token != "" {
+		status.Valid = clock.Now().Before(expiresAt)
+		status.Fingerprint = Fingerprint(token)
+	}
+	return status, nil
+}
 
-				a.logger.Info(a.ctx, "Auto-refresh: Token successfully refreshed")
+// Fingerprint returns a short hash of the token to match it in logs.
+func Fingerprint(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:6])
+}
 
-			case <-a.ctx.Done():
-				a.logger.Info(a.ctx, "Auto-refresh: Shutting down goroutine")
-				return
-			}
-		}
-	}()
+// StartAutoRefresh starts the goroutine renewing the cached tokens. Only the first
+// call has an effect; the goroutine runs until Close.
+func (a *GopayAuthenticator) StartAutoRefresh() {
+	a.refresh.Do(a.startAutoRefresh)
 }
 
-// Close cancels the authenticator's context, signaling the auto-refresh goroutine to exit.
-// This method is non-blocking and does not guarantee the goroutine has exited before returning.
+// Close cancels the authenticator's context, aborting a refresh in progress, and
+// waits until the auto-refresh goroutine has exited.
 func (a *GopayAuthenticator) Close() {
 	a.cancel()
+	a.wg.Wait()
 }
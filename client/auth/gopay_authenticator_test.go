@@ -0,0 +1,51 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/tkliner/go-gopay/client/config"
+	"github.com/tkliner/go-gopay/client/logger"
+	"github.com/tkliner/go-gopay/client/storage/inmemory"
+)
+
+func TestTokenErrorCode(t *testing.T) {
+	tests := map[string]struct {
+		body        string
+		code        string
+		description string
+	}{
+		"oauth":   {`{"error":"invalid_scope","error_description":"scope payment-all not allowed"}`, TokenErrorInvalidScope, "scope payment-all not allowed"},
+		"no code": {`invalid scope`, "", ""},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			cfg := config.NewConfig(
+				config.WithGatewayURL(srv.URL),
+				config.WithCredentials(8123456789, "client", "secret"),
+			)
+			a := NewGopayAuthenticator(inmemory.NewInMemoryTokenStorage(), srv.Client(), cfg, logger.NewNoOpLogger())
+			defer a.Close()
+
+			_, err := a.GetAccessToken(context.Background())
+
+			var tokenErr *TokenError
+			if !errors.As(err, &tokenErr) {
+				t.Fatalf("expected *TokenError, got %v", err)
+			}
+			if tokenErr.StatusCode != http.StatusBadRequest || tokenErr.Code != tt.code || tokenErr.Description != tt.description {
+				t.Errorf("unexpected token error %+v", tokenErr)
+			}
+		})
+	}
+}
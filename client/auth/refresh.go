@@ -0,0 +1,214 @@
+package auth
+
+import (
+	"math/rand/v2"
+	"time"
+
+	"github.com/tkliner/go-gopay/client/config"
+)
+
+const (
+	defaultRefreshLeadTime    = 5 * time.Minute
+	defaultRefreshJitter      = 30 * time.Second
+	defaultRefreshMinBackoff  = time.Second
+	defaultRefreshMaxBackoff  = time.Minute
+	defaultRefreshIdleTimeout = 30 * time.Minute
+)
+
+// refreshState is the schedule of one scope token.
+type refreshState struct {
+	expiresAt time.Time
+	due       time.Time
+	failures  int
+}
+
+func refreshOptions(cfg *config.Config) config.TokenRefresh {
+	var r config.TokenRefresh
+	if cfg.TokenRefresh != nil {
+		r = *cfg.TokenRefresh
+	}
+	if r.LeadTime == 0 {
+		r.LeadTime = defaultRefreshLeadTime
+	}
+	if r.Jitter == 0 {
+		r.Jitter = defaultRefreshJitter
+	}
+	if r.MinBackoff == 0 {
+		r.MinBackoff = defaultRefreshMinBackoff
+	}
+	if r.MaxBackoff == 0 {
+		r.MaxBackoff = defaultRefreshMaxBackoff
+	}
+	if r.IdleTimeout == 0 {
+		r.IdleTimeout = defaultRefreshIdleTimeout
+	}
+	return r
+}
+
+// touch marks the client as used and resumes a paused refresh.
+func (a *GopayAuthenticator) touch() {
+	a.lastUsed.Store(clock.Now().UnixNano())
+	if a.paused.Load() {
+		a.reschedule()
+	}
+}
+
+func (a *GopayAuthenticator) reschedule() {
+	select {
+	case a.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (a *GopayAuthenticator) idle(opts config.TokenRefresh) bool {
+	if opts.IdleTimeout < 0 {
+		return false
+	}
+	return clock.Now().Sub(time.Unix(0, a.lastUsed.Load())) > opts.IdleTimeout
+}
+
+func (a *GopayAuthenticator) startAutoRefresh() {
+	opts := refreshOptions(a.cfg)
+
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+
+		states := map[config.TokenScope]*refreshState{}
+
+		for {
+			scope, due, ok := a.nextRefresh(states, opts)
+
+			var fire <-chan time.Time
+			stop := func() bool { return false }
+			if ok {
+				fire, stop = clock.NewTimer(due.Sub(clock.Now()))
+			}
+
+			select {
+			case <-a.ctx.Done():
+				stop()
+				a.logger.Info(a.ctx, "Auto-refresh: Shutting down goroutine")
+				return
+			case <-a.wake:
+				stop()
+			case <-fire:
+				if !a.idle(opts) {
+					a.refreshScope(scope, states[scope], opts)
+				}
+			}
+		}
+	}()
+}
+
+// nextRefresh returns the scope whose token is due first. It returns false when
+// there is no token to refresh or the client is idle.
+func (a *GopayAuthenticator) nextRefresh(states map[config.TokenScope]*refreshState, opts config.TokenRefresh) (config.TokenScope, time.Time, bool) {
+	if a.idle(opts) {
+		if !a.paused.Swap(true) {
+			a.logger.Info(a.ctx, "Auto-refresh: Paused, client is idle")
+		}
+		return "", time.Time{}, false
+	}
+	if a.paused.Swap(false) {
+		a.logger.Info(a.ctx, "Auto-refresh: Resumed")
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var next config.TokenScope
+	var nextDue time.Time
+	found := false
+
+	for _, scope := range a.scopes() {
+		if _, ok := a.fetches[scope]; ok {
+			// The token is being renewed, the refresh is rescheduled when it is saved.
+			continue
+		}
+		token, expiresAt, err := a.storageFor(scope).GetAccessToken()
+		if err != nil || token == "" {
+			continue
+		}
+
+		st := states[scope]
+		if st == nil || !st.expiresAt.Equal(expiresAt) {
+			earliest := clock.Now()
+			if st != nil {
+				// The token was just renewed, do not renew it again right away even
+				// if its lifetime is shorter than the lead time.
+				earliest = earliest.Add(opts.MinBackoff)
+			}
+			st = &refreshState{expiresAt: expiresAt, due: refreshDue(expiresAt, earliest, opts)}
+			states[scope] = st
+		}
+
+		if !found || st.due.Before(nextDue) {
+			next, nextDue, found = scope, st.due, true
+		}
+	}
+
+	return next, nextDue, found
+}
+
+// refreshDue returns the time to renew a token expiring at expiresAt, but not
+// before earliest.
+func refreshDue(expiresAt, earliest time.Time, opts config.TokenRefresh) time.Time {
+	lead := opts.LeadTime
+	if remaining := expiresAt.Sub(clock.Now()); lead > remaining/2 {
+		lead = remaining / 2
+	}
+	if opts.Jitter > 0 {
+		lead += rand.N(opts.Jitter)
+	}
+	due := expiresAt.Add(-lead)
+	if due.Before(earliest) {
+		return earliest
+	}
+	return due
+}
+
+func (a *GopayAuthenticator) refreshScope(scope config.TokenScope, st *refreshState, opts config.TokenRefresh) {
+	a.logger.Info(a.ctx, "Auto-refresh: Starting token renewal", "scope", scope)
+
+	// The token is requested without holding a.mu, so that API calls with cached tokens
+	// and Status are not held up by a slow token endpoint.
+	a.mu.Lock()
+	f, shared := a.startFetch(scope)
+	a.mu.Unlock()
+
+	if shared {
+		select {
+		case <-f.done:
+		case <-a.ctx.Done():
+			return
+		}
+	} else {
+		a.runFetch(a.ctx, scope, f)
+	}
+	expiresAt, err := f.expiresAt, f.err
+
+	if err != nil {
+		if a.ctx.Err() != nil {
+			return
+		}
+		st.failures++
+		backoff := opts.MinBackoff << (st.failures - 1)
+		if backoff > opts.MaxBackoff || backoff <= 0 {
+			backoff = opts.MaxBackoff
+		}
+		st.due = clock.Now().Add(backoff)
+		a.logger.Warn(a.ctx, "Auto-refresh: Failed to refresh token", "scope", scope, "failures", st.failures, "retry_in", backoff, "error", err)
+		a.notifyRefresh(opts, config.RefreshEvent{Scope: scope, Err: err, Failures: st.failures})
+		return
+	}
+
+	a.logger.Info(a.ctx, "Auto-refresh: Token successfully refreshed", "scope", scope, "expires_at", expiresAt)
+	a.notifyRefresh(opts, config.RefreshEvent{Scope: scope, ExpiresAt: expiresAt})
+}
+
+func (a *GopayAuthenticator) notifyRefresh(opts config.TokenRefresh, event config.RefreshEvent) {
+	if opts.OnRefresh != nil {
+		opts.OnRefresh(event)
+	}
+}
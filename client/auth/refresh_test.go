@@ -0,0 +1,253 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/tkliner/go-gopay/client/config"
+	"github.com/tkliner/go-gopay/client/logger"
+	"github.com/tkliner/go-gopay/client/storage/inmemory"
+)
+
+// fakeClock replaces the clock of the package for the duration of the test. Its time
+// moves only by Advance.
+type fakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+}
+
+type fakeTimer struct {
+	at     time.Time
+	c      chan time.Time
+	active bool
+}
+
+// useFakeClock must be called before the authenticator is created, so that the clock
+// is restored after the authenticator is closed.
+func useFakeClock(t *testing.T) *fakeClock {
+	c := &fakeClock{now: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+	saved := clock
+	t.Cleanup(func() { clock = saved })
+	clock = c
+	return c
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) NewTimer(d time.Duration) (<-chan time.Time, func() bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	timer := &fakeTimer{at: c.now.Add(d), c: make(chan time.Time, 1), active: true}
+	if d <= 0 {
+		timer.c <- c.now
+		timer.active = false
+	}
+	c.timers = append(c.timers, timer)
+
+	return timer.c, func() bool {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		stopped := timer.active
+		timer.active = false
+		return stopped
+	}
+}
+
+// Advance moves the time by d and fires the timers that are due.
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+	for _, timer := range c.timers {
+		if timer.active && !timer.at.After(c.now) {
+			timer.c <- c.now
+			timer.active = false
+		}
+	}
+}
+
+// next waits until a timer is running and returns the time left until it fires.
+func (c *fakeClock) next(t *testing.T) time.Duration {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		c.mu.Lock()
+		for _, timer := range c.timers {
+			if timer.active {
+				wait := timer.at.Sub(c.now)
+				c.mu.Unlock()
+				return wait
+			}
+		}
+		c.mu.Unlock()
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatal("no timer was started")
+	return 0
+}
+
+// newTokenServer issues tokens valid for one second. After the first token it
+// fails while failing is set.
+func newTokenServer(t *testing.T, failing *atomic.Bool) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+
+	var issued atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if issued.Load() > 0 && failing != nil && failing.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		issued.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"token_type":"bearer","access_token":"token","expires_in":1}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, &issued
+}
+
+func newRefreshingAuthenticator(t *testing.T, srv *httptest.Server, refresh config.TokenRefresh) *GopayAuthenticator {
+	t.Helper()
+
+	cfg := config.NewConfig(
+		config.WithGatewayURL(srv.URL),
+		config.WithCredentials(8123456789, "client", "secret"),
+		config.WithTokenRefresh(refresh),
+	)
+	a := NewGopayAuthenticator(inmemory.NewInMemoryTokenStorage(), srv.Client(), cfg, logger.NewNoOpLogger())
+	t.Cleanup(a.Close)
+
+	if _, err := a.GetAccessToken(context.Background()); err != nil {
+		t.Fatalf("GetAccessToken: %v", err)
+	}
+	return a
+}
+
+func TestAutoRefreshBeforeExpiry(t *testing.T) {
+	clk := useFakeClock(t)
+	srv, issued := newTokenServer(t, nil)
+
+	events := make(chan config.RefreshEvent, 10)
+	newRefreshingAuthenticator(t, srv, config.TokenRefresh{
+		LeadTime:  300 * time.Millisecond,
+		Jitter:    -1,
+		OnRefresh: func(e config.RefreshEvent) { events <- e },
+	})
+
+	// The token is valid for a second and is renewed 300ms before it expires.
+	if wait := clk.next(t); wait != 700*time.Millisecond {
+		t.Fatalf("refresh scheduled in %s, expected 700ms", wait)
+	}
+	clk.Advance(700 * time.Millisecond)
+
+	select {
+	case e := <-events:
+		if e.Err != nil {
+			t.Fatalf("refresh failed: %v", e.Err)
+		}
+		if e.Scope != config.TokenScopeAll || !e.ExpiresAt.Equal(clk.Now().Add(time.Second)) {
+			t.Errorf("unexpected event %+v", e)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("token was not refreshed")
+	}
+
+	if issued.Load() != 2 {
+		t.Errorf("expected 2 tokens, %d issued", issued.Load())
+	}
+}
+
+func TestAutoRefreshBackoff(t *testing.T) {
+	clk := useFakeClock(t)
+	var failing atomic.Bool
+	failing.Store(true)
+	srv, _ := newTokenServer(t, &failing)
+
+	events := make(chan config.RefreshEvent, 10)
+	newRefreshingAuthenticator(t, srv, config.TokenRefresh{
+		LeadTime:   900 * time.Millisecond,
+		Jitter:     -1,
+		MinBackoff: 20 * time.Millisecond,
+		MaxBackoff: 40 * time.Millisecond,
+		OnRefresh:  func(e config.RefreshEvent) { events <- e },
+	})
+
+	// The lead time is capped at half of the token lifetime, the retries back off
+	// up to the max backoff.
+	for attempt, want := range []time.Duration{500 * time.Millisecond, 20 * time.Millisecond, 40 * time.Millisecond, 40 * time.Millisecond} {
+		wait := clk.next(t)
+		if wait != want {
+			t.Errorf("attempt %d scheduled in %s, expected %s", attempt+1, wait, want)
+		}
+		clk.Advance(wait)
+
+		select {
+		case e := <-events:
+			if e.Err == nil || e.Failures != attempt+1 {
+				t.Fatalf("expected failure %d, got %+v", attempt+1, e)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no refresh attempt %d", attempt+1)
+		}
+	}
+
+	failing.Store(false)
+	clk.Advance(clk.next(t))
+
+	select {
+	case e := <-events:
+		if e.Err != nil || e.Failures != 0 {
+			t.Fatalf("expected success, got %+v", e)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("refresh did not recover")
+	}
+}
+
+func TestAutoRefreshPausesWhenIdle(t *testing.T) {
+	clk := useFakeClock(t)
+	srv, issued := newTokenServer(t, nil)
+
+	var refreshed atomic.Int32
+	a := newRefreshingAuthenticator(t, srv, config.TokenRefresh{
+		LeadTime:    300 * time.Millisecond,
+		Jitter:      -1,
+		IdleTimeout: 100 * time.Millisecond,
+		OnRefresh:   func(config.RefreshEvent) { refreshed.Add(1) },
+	})
+
+	// The client is idle when the refresh is due.
+	clk.Advance(clk.next(t))
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !a.paused.Load() {
+		if time.Now().After(deadline) {
+			t.Fatal("refresh was not paused")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if refreshed.Load() != 0 {
+		t.Fatalf("idle client refreshed %d times", refreshed.Load())
+	}
+
+	clk.Advance(time.Second)
+	if _, err := a.GetAccessToken(context.Background()); err != nil {
+		t.Fatalf("GetAccessToken: %v", err)
+	}
+	if issued.Load() != 2 {
+		t.Errorf("expected the expired token to be renewed on use, %d issued", issued.Load())
+	}
+}
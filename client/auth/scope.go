@@ -0,0 +1,33 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/tkliner/go-gopay/client/config"
+)
+
+type scopeKey struct{}
+
+// WithScope returns a context requesting an access token of the scope. Requests
+// made with the context are authorized by a token of exactly that scope.
+func WithScope(ctx context.Context, scope config.TokenScope) context.Context {
+	return context.WithValue(ctx, scopeKey{}, scope)
+}
+
+// ScopeFromContext returns the scope requested by WithScope.
+func ScopeFromContext(ctx context.Context) (config.TokenScope, bool) {
+	scope, ok := ctx.Value(scopeKey{}).(config.TokenScope)
+	return scope, ok
+}
+
+// ScopeError is returned when a request needs a broader scope than the client was
+// configured with. The request was not sent.
+type ScopeError struct {
+	Required config.TokenScope
+	Granted  config.TokenScope
+}
+
+func (e *ScopeError) Error() string {
+	return fmt.Sprintf("scope %s is required, client is limited to %s", e.Required, e.Granted)
+}
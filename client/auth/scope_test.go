@@ -0,0 +1,86 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/tkliner/go-gopay/client/config"
+	"github.com/tkliner/go-gopay/client/logger"
+	"github.com/tkliner/go-gopay/client/storage/inmemory"
+)
+
+func TestScopedTokenFetch(t *testing.T) {
+	release := make(chan struct{})
+	var createRequests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("scope") == string(config.TokenScopeCreatePayment) {
+			createRequests.Add(1)
+			<-release
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"token_type":"bearer","access_token":"token","expires_in":1800}`))
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	cfg := config.NewConfig(
+		config.WithGatewayURL(srv.URL),
+		config.WithCredentials(8123456789, "client", "secret"),
+	)
+	a := NewGopayAuthenticator(inmemory.NewInMemoryTokenStorage(), srv.Client(), cfg, logger.NewNoOpLogger())
+	defer a.Close()
+
+	if _, err := a.GetAccessToken(context.Background()); err != nil {
+		t.Fatalf("GetAccessToken: %v", err)
+	}
+
+	createCtx := WithScope(context.Background(), config.TokenScopeCreatePayment)
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			a.GetAccessToken(createCtx)
+		}()
+	}
+	for createRequests.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// The hanging payment-create request holds up neither the cached token nor Status.
+	done := make(chan error, 1)
+	go func() {
+		_, err := a.GetAccessToken(context.Background())
+		if err == nil {
+			_, err = a.Status()
+		}
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("cached token blocked by a token request of another scope")
+	}
+
+	// A caller waiting for the shared request gives up with its own context.
+	ctx, cancel := context.WithTimeout(createCtx, 10*time.Millisecond)
+	defer cancel()
+	if _, err := a.GetAccessToken(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline, got %v", err)
+	}
+
+	release <- struct{}{}
+	wg.Wait()
+	if createRequests.Load() != 1 {
+		t.Errorf("expected one shared payment-create request, got %d", createRequests.Load())
+	}
+}
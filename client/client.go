@@ -1,6 +1,7 @@
 package client
 
 import (
+	"fmt"
 	"net/http"
 	"net/url"
 
@@ -10,6 +11,7 @@ import (
 )
 
 type Interface interface {
+	Method(verb string) *Request
 	Post() *Request
 	Put() *Request
 	Patch() *Request
@@ -21,21 +23,33 @@ type Client struct {
 	base *url.URL
 	client *http.Client
 	content Content
+	serializers map[string]Serializer
 
 	authenticator auth.Authenticator
 
 	logger logger.Logger
+
+	strict bool
 }
 
+// Content holds the default media types of requests and responses.
 type Content struct {
+	// ContentType is used to encode request bodies unless a request selects another.
 	ContentType string
+	// AcceptContentTypes is sent in the Accept header unless a request sets it.
+	AcceptContentTypes string
 }
 
 func NewClient(cfg *config.Config, httpClient *http.Client) (*Client, error) {
 
-	// TODO: make it configurable
+	contentType := cfg.ContentType
+	if contentType == "" {
+		contentType = config.DefaultContentType
+	}
+
 	content := Content{
-		ContentType: "application/json",
+		ContentType:        contentType,
+		AcceptContentTypes: contentType,
 	}
 
 	baseURL, err := createBaseURL(cfg.GatewayURL)
@@ -46,8 +60,18 @@ func NewClient(cfg *config.Config, httpClient *http.Client) (*Client, error) {
 	c := &Client {
 		base: baseURL,
 		content: content,
+		serializers: defaultSerializers(),
 		client: httpClient,
 		logger: cfg.Logger,
+		strict: cfg.StrictDecoding,
+	}
+
+	for _, s := range cfg.Serializers {
+		c.RegisterSerializer(s)
+	}
+
+	if _, ok := c.serializer(contentType); !ok {
+		return nil, fmt.Errorf("unsupported content type %q", contentType)
 	}
 
 	return c, nil
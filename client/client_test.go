@@ -3,12 +3,16 @@ package client
 import (
 	"context"
 	//"encoding/base64"
-	"fmt"
+	"errors"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	//"strings"
 	"testing"
+	"time"
+
+	"github.com/tkliner/go-gopay/client/config"
 )
 
 // createTestServer returns a mock server that acts as both token and API endpoint.
@@ -109,37 +113,129 @@ func (m *mockLogger) Trace(ctx context.Context, msg string, _ ...any) { m.LastMe
 // 	return nil
 // }
 
-func TestMock( _ *testing.T) {
-	goPay, err := NewClient(
-		WithGatewayURL("https://gw.sandbox.gopay.com"),
-		WithCredentials(8836046164, "1253288454", "Cdf5ChEA"),
+// bearerTransport adds the token the way the auth round tripper does.
+type bearerTransport struct {
+	token string
+}
+
+func (b bearerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+b.token)
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func newTestClient(t *testing.T, gatewayURL string) *Client {
+	t.Helper()
+
+	cfg := config.NewConfig(
+		config.WithGatewayURL(gatewayURL),
+		config.WithLogger(&mockLogger{}),
 	)
 
+	c, err := NewClient(cfg, &http.Client{Transport: bearerTransport{token: "mock-token"}})
 	if err != nil {
-		panic(err)
+		t.Fatalf("NewClient: %v", err)
 	}
+	return c
+}
 
-	resp, err := goPay.Payment.Get(context.Background(), 3283981064)
+func TestMock(t *testing.T) {
+	srv := createTestServer(t, "", "mock-token")
+	defer srv.Close()
 
+	body, err := newTestClient(t, srv.URL).Get().Resource("/payments/payment/1").Do(context.Background()).Raw()
 	if err != nil {
-		panic(err)
+		t.Fatalf("Do: %v", err)
+	}
+	if string(body) != "Test successful" {
+		t.Fatalf("unexpected body %q", body)
 	}
+}
 
-	fmt.Printf("Response: %+v\n", resp)
-	
-	r, _ := io.ReadAll(resp.Body)
+func TestRequestURL(t *testing.T) {
+	c := newTestClient(t, "https://gw.sandbox.gopay.com")
 
-	fmt.Println("Body:")
-	fmt.Println(string(r))
+	u, err := c.Get().
+		Resource("/eshops/eshop/{goid}/payment-instruments/{currency}").
+		PathParam("goid", int64(8123456789)).
+		PathParam("currency", "a/b c").
+		Param("lang", "CS").
+		buildURL()
+	if err != nil {
+		t.Fatalf("buildURL: %v", err)
+	}
 
+	want := "https://gw.sandbox.gopay.com/api/eshops/eshop/8123456789/payment-instruments/a%2Fb%20c?lang=CS"
+	if u.String() != want {
+		t.Fatalf("got %s, want %s", u, want)
+	}
+}
 
+func TestRequestPathParamErrors(t *testing.T) {
+	c := newTestClient(t, "https://gw.sandbox.gopay.com")
 
-	cfg := NewConfig(
-		WithGatewayURL("https://gw.sandbox.gopay.com"),
-		WithCredentials(8836046164, "1253288454", "Cdf5ChEA"),
-	)
+	tests := map[string]*Request{
+		"missing": c.Get().Resource("/payments/payment/{id}"),
+		"empty":   c.Get().Resource("/payments/payment/{id}").PathParam("id", ""),
+		"dotdot":  c.Get().Resource("/payments/payment/{id}").PathParam("id", ".."),
+	}
 
-	client, err := New(cfg, http.DefaultClient)
+	for name, req := range tests {
+		t.Run(name, func(t *testing.T) {
+			if err := req.Do(context.Background()).Error(); !errors.As(err, new(*BuildError)) {
+				t.Fatalf("expected build error, got %v", err)
+			}
+		})
+	}
+}
 
+func TestRequestHeadersAndBody(t *testing.T) {
+	var got *http.Request
+	var gotBody string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		got, gotBody = r, string(data)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	err := newTestClient(t, srv.URL).Post().
+		Resource("/payments/payment/{id}/refund").
+		PathParam("id", 42).
+		FormBody(url.Values{"amount": {"100"}}).
+		SetHeader("Idempotency-Key", "refund-42").
+		Do(context.Background()).
+		Error()
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
 
-}
\ No newline at end of file
+	if got.URL.Path != "/api/payments/payment/42/refund" {
+		t.Errorf("unexpected path %s", got.URL.Path)
+	}
+	if ct := got.Header.Get("Content-Type"); ct != "application/x-www-form-urlencoded" {
+		t.Errorf("unexpected content type %s", ct)
+	}
+	if key := got.Header.Get("Idempotency-Key"); key != "refund-42" {
+		t.Errorf("unexpected idempotency key %s", key)
+	}
+	if gotBody != "amount=100" {
+		t.Errorf("unexpected body %s", gotBody)
+	}
+}
+
+func TestRequestTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	err := newTestClient(t, srv.URL).Get().
+		Resource("/payments/payment/1").
+		Timeout(20 * time.Millisecond).
+		Do(context.Background()).
+		Error()
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+}
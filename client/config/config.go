@@ -11,6 +11,13 @@ const (
 	// Výchozí hodnoty
 	DefaultLanguage = "cs"
 	DefaultTimeout  = 30 * time.Second
+	DefaultContentType = "application/json"
+)
+
+// Gateway URLs of the GoPay environments, see WithGatewayURL.
+const (
+	SandboxGatewayURL    = "https://gw.sandbox.gopay.com"
+	ProductionGatewayURL = "https://gate.gopay.cz"
 )
 
 type Config struct {
@@ -23,12 +30,41 @@ type Config struct {
 	Timeout            time.Duration
 	IsProduction       bool
 	TokenStorage       storage.TokenStorage
+	// IdempotencyStorage enables idempotent payment creation keyed by order number.
+	IdempotencyStorage storage.IdempotencyStorage
+	// PendingExpiry lets a creation with unknown outcome be retried once its
+	// reservation is older than this, zero keeps it until resolved.
+	PendingExpiry time.Duration
 	Logger             logger.Logger
 	EnableMetrics      bool
 	AutoRefresh bool
+	// TokenRefresh tunes the auto refresh, defaults are used when nil.
+	TokenRefresh *TokenRefresh
+	// RateLimits throttles requests per endpoint class on the client side.
+	RateLimits *RateLimits
+	// CircuitBreaker makes calls fail fast while the gateway is degraded.
+	CircuitBreaker *CircuitBreaker
+	// StrictDecoding reports response fields unknown to the models as *client.UnknownFieldsError.
+	StrictDecoding bool
+	// ContentType is the default media type of request bodies and accepted responses.
+	ContentType string
+	// Serializers handle media types other than JSON and form, see WithSerializer.
+	Serializers []Serializer
+	// Authenticator replaces the built-in GoPay OAuth authenticator.
+	Authenticator TokenSource
+	// CredentialsProvider supplies ClientId and ClientSecret on each token request.
+	CredentialsProvider CredentialsProvider
+	// Middleware are custom transport layers, see WithMiddleware.
+	Middleware []Middleware
+	// Transport tunes the connections to the gateway, http.DefaultTransport is used when nil.
+	Transport *Transport
+	// EagerStartup makes gopay.New verify the credentials and GoID with the gateway.
+	EagerStartup bool
+	// PingCurrency selects the payment instruments listed by gopay.Ping, CZK when empty.
+	PingCurrency string
 }
 
-func NewConfig(opts ...func(c *Config)) *Config {
+func NewConfig(opts ...Option) *Config {
 	cfg := &Config{}
 
 	for _, option := range opts {
@@ -52,11 +88,13 @@ func (c *Config) Validate() error {
 	if c.GoId == 0 {
 		return &ValidationError{Message: "GoId is a mandatory parameter and cannot be zero"}
 	}
-	if c.ClientId == "" {
-		return &ValidationError{Message: "ClientId is a mandatory parameter"}
-	}
-	if c.ClientSecret == "" {
-		return &ValidationError{Message: "ClientSecret is a mandatory parameter"}
+	if c.Authenticator == nil && c.CredentialsProvider == nil {
+		if c.ClientId == "" {
+			return &ValidationError{Message: "ClientId is a mandatory parameter"}
+		}
+		if c.ClientSecret == "" {
+			return &ValidationError{Message: "ClientSecret is a mandatory parameter"}
+		}
 	}
 	if c.GatewayURL == "" {
 		return &ValidationError{Message: "GatewayURL is a mandatory parameter"}
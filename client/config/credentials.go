@@ -0,0 +1,22 @@
+package config
+
+import "context"
+
+// TokenSource provides access tokens for the API requests, see WithAuthenticator.
+// A source implementing auth.Authenticator also reports the status of its token.
+type TokenSource interface {
+	// GetAccessToken returns a valid access token, see auth.WithScope for the scope.
+	GetAccessToken(ctx context.Context) (string, error)
+}
+
+// Credentials are the OAuth client credentials of the e-shop.
+type Credentials struct {
+	ClientId     string
+	ClientSecret string
+}
+
+// CredentialsProvider is consulted on each token request, so that rotated
+// credentials are used without restarting the client.
+type CredentialsProvider interface {
+	Credentials(ctx context.Context) (Credentials, error)
+}
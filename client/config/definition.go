@@ -1,8 +1,122 @@
 package config
 
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"time"
+)
+
 type TokenScope string
 type Language string
 
+// Middleware wraps the transport of API requests with a custom layer.
+type Middleware func(next http.RoundTripper) http.RoundTripper
+
+// RateLimit is a token bucket budget allowing Rate requests per second with bursts
+// of up to Burst requests. A zero Rate disables the limit.
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+// CircuitBreaker configures the circuit breaker around the gateway. Zero values
+// are replaced by the defaults noted on the fields.
+type CircuitBreaker struct {
+	// Window is the period over which error rate and latency are evaluated (30s).
+	Window time.Duration
+	// MinRequests is the number of calls in the window needed before the breaker may open (10).
+	MinRequests int
+	// FailureRate opens the breaker when the share of failed calls reaches it (0.5).
+	FailureRate float64
+	// SlowCallDuration marks calls taking longer as slow (5s).
+	SlowCallDuration time.Duration
+	// SlowCallRate opens the breaker when the share of slow calls reaches it (0.8).
+	SlowCallRate float64
+	// OpenTimeout is how long the breaker stays open before probing the gateway (30s).
+	OpenTimeout time.Duration
+	// HalfOpenRequests is the number of successful probes needed to close the breaker (1).
+	HalfOpenRequests int
+}
+
+// Transport tunes the connections to the gateway, used for both API and token
+// requests. Zero values keep the defaults of http.DefaultTransport.
+type Transport struct {
+	// ProxyURL routes all requests through the proxy. If empty, the proxy is taken
+	// from the HTTPS_PROXY and NO_PROXY environment variables.
+	ProxyURL string
+	// RootCAs replaces the system certificate pool, e.g. with a pinned CA bundle.
+	RootCAs *x509.CertPool
+	// Certificates are presented to the gateway for mutual TLS.
+	Certificates []tls.Certificate
+	// MinTLSVersion is the minimum TLS version, e.g. tls.VersionTLS13 (TLS 1.2).
+	MinTLSVersion uint16
+	// DisableHTTP2 restricts the connections to HTTP/1.1.
+	DisableHTTP2 bool
+	// MaxIdleConns limits the idle connections in the pool (100).
+	MaxIdleConns int
+	// MaxIdleConnsPerHost limits the idle connections kept to the gateway (10).
+	MaxIdleConnsPerHost int
+	// MaxConnsPerHost limits all connections to the gateway, zero means no limit.
+	MaxConnsPerHost int
+	// IdleConnTimeout closes connections idle for longer (90s).
+	IdleConnTimeout time.Duration
+	// KeepAlive is the interval of TCP keep-alive probes (30s).
+	KeepAlive time.Duration
+	// TLSHandshakeTimeout limits the TLS handshake (10s).
+	TLSHandshakeTimeout time.Duration
+}
+
+// TokenRefresh configures the auto refresh of access tokens. Zero values are
+// replaced by the defaults noted on the fields.
+type TokenRefresh struct {
+	// LeadTime is how long before the token expiry the token is renewed (5m).
+	// It is capped to half of the remaining token lifetime.
+	LeadTime time.Duration
+	// Jitter moves each refresh earlier by a random duration of up to Jitter (30s),
+	// so that many clients do not renew their tokens at once. A negative value
+	// disables the jitter.
+	Jitter time.Duration
+	// MinBackoff is the delay before retrying a failed refresh, doubled after each
+	// consecutive failure (1s).
+	MinBackoff time.Duration
+	// MaxBackoff caps the delay between retries (1m).
+	MaxBackoff time.Duration
+	// IdleTimeout pauses the refresh when no token was requested for this long (30m).
+	// The refresh resumes on the next request. A negative value never pauses.
+	IdleTimeout time.Duration
+	// OnRefresh, if set, is called after each refresh attempt.
+	OnRefresh func(RefreshEvent)
+}
+
+// RefreshEvent describes one attempt to refresh an access token.
+type RefreshEvent struct {
+	Scope TokenScope
+	// ExpiresAt is the expiry of the new token, zero if the refresh failed.
+	ExpiresAt time.Time
+	Err       error
+	// Failures is the number of consecutive failed attempts, zero on success.
+	Failures int
+}
+
+// RateLimits holds separate budgets for the endpoint classes of the GoPay API.
+type RateLimits struct {
+	// Token limits requests for OAuth access tokens.
+	Token RateLimit
+	// PaymentRead limits payment status and other read requests.
+	PaymentRead RateLimit
+	// PaymentCreate limits payment creation and other write requests.
+	PaymentCreate RateLimit
+	// Refund limits payment refunds.
+	Refund RateLimit
+}
+
+// Covers reports whether a token of scope s is allowed for the required scope.
+// payment-all covers every scope, an empty scope is treated as payment-all.
+func (s TokenScope) Covers(required TokenScope) bool {
+	return s == "" || s == TokenScopeAll || s == required
+}
+
 const (
 	TokenScopeCreatePayment TokenScope = "payment-create"
 	TokenScopeAll           TokenScope = "payment-all"
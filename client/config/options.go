@@ -29,6 +29,25 @@ func WithTokenStorage(ts storage.TokenStorage) Option {
 	}
 }
 
+// WithIdempotencyStorage makes payment creation idempotent per order number, see
+// storage.IdempotencyStorage.
+func WithIdempotencyStorage(s storage.IdempotencyStorage) Option {
+	return func(c *Config) {
+		c.IdempotencyStorage = s
+	}
+}
+
+// WithPendingExpiry releases an order number left pending by a creation with unknown
+// outcome, e.g. a timeout, once it is older than d, so that CreatePayment may send it
+// again. If the lost creation did succeed, GoPay then holds two payments for the order,
+// the first of which was never shown to the customer and expires unpaid. d must be
+// well above the request timeout.
+func WithPendingExpiry(d time.Duration) Option {
+	return func(c *Config) {
+		c.PendingExpiry = d
+	}
+}
+
 func WithLogger(l logger.Logger) Option {
 	return func(c *Config) {
 		c.Logger = l
@@ -69,4 +88,102 @@ func WithAutoRefresh() Option {
 	return func(c *Config) {
 		c.AutoRefresh = true
 	}
-}
\ No newline at end of file
+}
+
+// WithTokenRefresh enables the auto refresh of access tokens with the given timing.
+func WithTokenRefresh(r TokenRefresh) Option {
+	return func(c *Config) {
+		c.AutoRefresh = true
+		c.TokenRefresh = &r
+	}
+}
+
+// WithStrictDecoding enables reporting of response fields the models do not know.
+// It is meant for staging environments to notice changes of the GoPay API early.
+func WithStrictDecoding() Option {
+	return func(c *Config) {
+		c.StrictDecoding = true
+	}
+}
+
+// WithRateLimits enables client side rate limiting. Requests over the budget wait
+// until they fit in, or until their context is done.
+func WithRateLimits(limits RateLimits) Option {
+	return func(c *Config) {
+		c.RateLimits = &limits
+	}
+}
+
+// WithCircuitBreaker wraps the gateway calls in a circuit breaker.
+func WithCircuitBreaker(cb CircuitBreaker) Option {
+	return func(c *Config) {
+		c.CircuitBreaker = &cb
+	}
+}
+
+// WithContentType sets the default media type of request bodies and accepted
+// responses. JSON and form are built in, other media types need WithSerializer.
+func WithContentType(contentType string) Option {
+	return func(c *Config) {
+		c.ContentType = contentType
+	}
+}
+
+// WithSerializer adds or replaces the serializer for its content type. It is
+// registered before the content type set by WithContentType is checked.
+func WithSerializer(s Serializer) Option {
+	return func(c *Config) {
+		c.Serializers = append(c.Serializers, s)
+	}
+}
+
+// WithAuthenticator replaces the built-in authenticator, e.g. with one sharing
+// tokens between processes. The client does not close it.
+func WithAuthenticator(a TokenSource) Option {
+	return func(c *Config) {
+		c.Authenticator = a
+	}
+}
+
+// WithCredentialsProvider makes the built-in authenticator ask the provider for the
+// client credentials on each token request instead of using the static ones.
+func WithCredentialsProvider(p CredentialsProvider) Option {
+	return func(c *Config) {
+		c.CredentialsProvider = p
+	}
+}
+
+// WithMiddleware adds custom layers to the transport of API requests, e.g. retries,
+// logging or tracing. They run after the client checks that it is not closed and
+// before the built-in metrics, rate limiter, circuit breaker and authentication,
+// the first middleware outermost. They are not applied to token requests.
+func WithMiddleware(mw ...Middleware) Option {
+	return func(c *Config) {
+		c.Middleware = append(c.Middleware, mw...)
+	}
+}
+
+// WithTransport tunes the connections to the gateway, e.g. to use an egress proxy,
+// a pinned CA bundle or mutual TLS. It cannot be combined with NewWithClient
+// given a client with its own transport.
+func WithTransport(t Transport) Option {
+	return func(c *Config) {
+		c.Transport = &t
+	}
+}
+
+// WithEagerStartup makes gopay.New fetch the first token and check the GoID with the
+// gateway, so that bad credentials fail at startup instead of on the first payment.
+func WithEagerStartup() Option {
+	return func(c *Config) {
+		c.EagerStartup = true
+	}
+}
+
+// WithPingCurrency sets the currency of the payment instruments listed by gopay.Ping,
+// e.g. "EUR" for e-shops without CZK payments.
+func WithPingCurrency(currency string) Option {
+	return func(c *Config) {
+		c.PingCurrency = currency
+	}
+}
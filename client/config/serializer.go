@@ -0,0 +1,9 @@
+package config
+
+// Serializer encodes request bodies and decodes response bodies of one media type.
+// It is defined here so that serializers can be configured, see WithSerializer.
+type Serializer interface {
+	ContentType() string
+	Encode(v any) ([]byte, error)
+	Decode(data []byte, v any) error
+}
@@ -0,0 +1,131 @@
+package client
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// UnknownFieldsError is returned in strict decoding mode when the response contains
+// fields the target model does not declare. The target is fully decoded nevertheless,
+// so callers may log the error and continue.
+type UnknownFieldsError struct {
+	// Fields holds JSON paths of the unknown fields, e.g. "payer.contact.nickname".
+	Fields []string
+}
+
+func (e *UnknownFieldsError) Error() string {
+	return "response contains unknown fields: " + strings.Join(e.Fields, ", ")
+}
+
+var unmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+func checkUnknownFields(data []byte, obj any) error {
+	var fields []string
+	collectUnknownFields(data, reflect.TypeOf(obj), "", &fields)
+	if len(fields) == 0 {
+		return nil
+	}
+	sort.Strings(fields)
+	return &UnknownFieldsError{Fields: fields}
+}
+
+func collectUnknownFields(data []byte, t reflect.Type, path string, out *[]string) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if reflect.PointerTo(t).Implements(unmarshalerType) {
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		var obj map[string]json.RawMessage
+		if json.Unmarshal(data, &obj) != nil {
+			return
+		}
+		known := structFields(t)
+		for name, raw := range obj {
+			ft, ok := lookupField(known, name)
+			if !ok {
+				*out = append(*out, joinPath(path, name))
+				continue
+			}
+			collectUnknownFields(raw, ft, joinPath(path, name), out)
+		}
+	case reflect.Slice, reflect.Array:
+		var list []json.RawMessage
+		if json.Unmarshal(data, &list) != nil {
+			return
+		}
+		for i, raw := range list {
+			collectUnknownFields(raw, t.Elem(), path+"["+strconv.Itoa(i)+"]", out)
+		}
+	case reflect.Map:
+		var obj map[string]json.RawMessage
+		if json.Unmarshal(data, &obj) != nil {
+			return
+		}
+		for name, raw := range obj {
+			collectUnknownFields(raw, t.Elem(), joinPath(path, name), out)
+		}
+	}
+}
+
+// structFields returns the JSON names of the struct fields including promoted fields of embedded structs.
+func structFields(t reflect.Type) map[string]reflect.Type {
+	fields := map[string]reflect.Type{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				for n, t := range structFields(ft) {
+					if _, ok := fields[n]; !ok {
+						fields[n] = t
+					}
+				}
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[name] = f.Type
+	}
+	return fields
+}
+
+// lookupField matches names the same way encoding/json does, preferring an exact match.
+func lookupField(fields map[string]reflect.Type, name string) (reflect.Type, bool) {
+	if t, ok := fields[name]; ok {
+		return t, true
+	}
+	for n, t := range fields {
+		if strings.EqualFold(n, name) {
+			return t, true
+		}
+	}
+	return nil, false
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
@@ -0,0 +1,67 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// HeaderRequestID is the response header with the ID GoPay assigns to the request.
+const HeaderRequestID = "X-Request-Id"
+
+// ErrEmptyBody is returned by Do when a response other than 204 has no body.
+var ErrEmptyBody = errors.New("empty response body")
+
+// ResponseMeta describes the HTTP response of a request.
+type ResponseMeta struct {
+	StatusCode int
+	Header     http.Header
+	// Latency is the time from sending the request until the body was read.
+	Latency time.Duration
+	// RequestID identifies the request in GoPay support tickets, if sent.
+	RequestID string
+}
+
+// Meta returns the metadata of the response, or nil if no response was received.
+func (r Result) Meta() *ResponseMeta {
+	if r.statusCode == 0 {
+		return nil
+	}
+	return &ResponseMeta{
+		StatusCode: r.statusCode,
+		Header:     r.header,
+		Latency:    r.latency,
+		RequestID:  r.header.Get(HeaderRequestID),
+	}
+}
+
+// Do sends the request and decodes the response into a new T, see Result.Convert.
+// The metadata is returned whenever a response was received, also with an error.
+// A 204 response yields the zero T. With strict decoding, a response with unknown
+// fields is returned together with the *UnknownFieldsError.
+func Do[T any](ctx context.Context, req *Request) (*T, *ResponseMeta, error) {
+	result := req.Do(ctx)
+	meta := result.Meta()
+
+	if err := result.Error(); err != nil {
+		return nil, meta, err
+	}
+
+	out := new(T)
+	if len(result.body) == 0 {
+		if result.statusCode == http.StatusNoContent {
+			return out, meta, nil
+		}
+		return nil, meta, ErrEmptyBody
+	}
+
+	if err := result.Convert(out); err != nil {
+		var unknown *UnknownFieldsError
+		if errors.As(err, &unknown) {
+			return out, meta, err
+		}
+		return nil, meta, err
+	}
+	return out, meta, nil
+}
@@ -0,0 +1,92 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type testPayment struct {
+	Id    int64  `json:"id"`
+	State string `json:"state"`
+}
+
+func TestDo(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set(HeaderRequestID, "req-42")
+		switch r.URL.Path {
+		case "/api/payments/payment/1":
+			w.Write([]byte(`{"id": 1, "state": "PAID"}`))
+		case "/api/payments/payment/2":
+			w.WriteHeader(http.StatusOK)
+		case "/api/payments/payment/3":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errors": [{"error_code": 404}]}`))
+		}
+	}))
+	defer srv.Close()
+
+	c := newTestClient(t, srv.URL)
+	get := func(id int) *Request {
+		return c.Get().Resource("/payments/payment/{id}").PathParam("id", id)
+	}
+
+	payment, meta, err := Do[testPayment](context.Background(), get(1))
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	if payment.Id != 1 || payment.State != "PAID" {
+		t.Errorf("unexpected payment %+v", payment)
+	}
+	if meta.StatusCode != http.StatusOK || meta.RequestID != "req-42" || meta.Latency <= 0 || meta.Header.Get("Content-Type") == "" {
+		t.Errorf("unexpected meta %+v", meta)
+	}
+
+	if _, meta, err := Do[testPayment](context.Background(), get(2)); !errors.Is(err, ErrEmptyBody) || meta == nil {
+		t.Errorf("expected ErrEmptyBody with meta, got %v, %+v", err, meta)
+	}
+
+	if payment, _, err := Do[testPayment](context.Background(), get(3)); err != nil || payment == nil {
+		t.Errorf("expected zero value for 204, got %v, %v", payment, err)
+	}
+
+	_, meta, err = Do[testPayment](context.Background(), get(4))
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || meta == nil || meta.StatusCode != http.StatusNotFound {
+		t.Errorf("expected status error with meta, got %v, %+v", err, meta)
+	}
+}
+
+func TestDoTransportError(t *testing.T) {
+	c := newTestClient(t, "http://127.0.0.1:1")
+
+	_, meta, err := Do[testPayment](context.Background(), c.Get().Resource("/payments/payment/1"))
+	if err == nil || meta != nil {
+		t.Fatalf("expected error without meta, got %v, %+v", err, meta)
+	}
+}
+
+func TestResultConvertError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"id": 7, "state": "CREATED"}`))
+	}))
+	defer srv.Close()
+
+	var payment testPayment
+	err := newTestClient(t, srv.URL).Get().Resource("/payments/payment/7").Do(context.Background()).Convert(&payment)
+
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected status error, got %v", err)
+	}
+	if payment.Id != 0 {
+		t.Errorf("error body decoded into the target: %+v", payment)
+	}
+}
@@ -19,9 +19,11 @@ type AuthRoundTripper struct {
 func (rt *AuthRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
 	accessToken, err := rt.getAccessToken(req.Context())
 	if err != nil {
-		return nil, fmt.Errorf("failed to get access token: %w", err)
+		return nil, &AuthError{Err: err}
 	}
 
+	// A RoundTripper must not modify the request, it may be retried by an outer layer.
+	req = req.Clone(req.Context())
 	req.Header.Set("Authorization", "Bearer "+accessToken)
 
 	return rt.next.RoundTrip(req)
@@ -30,3 +32,16 @@ func (rt *AuthRoundTripper) RoundTrip(req *http.Request) (*http.Response, error)
 func (rt *AuthRoundTripper) getAccessToken(ctx context.Context) (string, error) {
 	return rt.authenticator.GetAccessToken(ctx)
 }
+
+// AuthError is returned when no access token could be obtained. The request was not sent.
+type AuthError struct {
+	Err error
+}
+
+func (e *AuthError) Error() string {
+	return fmt.Sprintf("failed to get access token: %v", e.Err)
+}
+
+func (e *AuthError) Unwrap() error {
+	return e.Err
+}
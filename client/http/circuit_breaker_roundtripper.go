@@ -0,0 +1,304 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/tkliner/go-gopay/client/auth"
+	"github.com/tkliner/go-gopay/client/config"
+	"github.com/tkliner/go-gopay/client/logger"
+)
+
+// CircuitState is the state of the circuit breaker.
+type CircuitState string
+
+const (
+	// CircuitDisabled is reported when no circuit breaker is configured.
+	CircuitDisabled CircuitState = "disabled"
+	// CircuitClosed lets all calls through.
+	CircuitClosed CircuitState = "closed"
+	// CircuitOpen rejects all calls until the open timeout passes.
+	CircuitOpen CircuitState = "open"
+	// CircuitHalfOpen lets a limited number of probe calls through.
+	CircuitHalfOpen CircuitState = "half-open"
+)
+
+const (
+	defaultBreakerWindow           = 30 * time.Second
+	defaultBreakerMinRequests      = 10
+	defaultBreakerFailureRate      = 0.5
+	defaultBreakerSlowCallDuration = 5 * time.Second
+	defaultBreakerSlowCallRate     = 0.8
+	defaultBreakerOpenTimeout      = 30 * time.Second
+	defaultBreakerHalfOpenRequests = 1
+
+	breakerBuckets = 10
+)
+
+// ErrCircuitOpen is matched by errors.Is for calls rejected by the circuit breaker.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// CircuitOpenError is returned without contacting the gateway while the breaker is open.
+type CircuitOpenError struct {
+	// RetryAfter is the time left until the breaker lets a probe call through or, while
+	// the probe calls are in flight, until their outcome is expected.
+	RetryAfter time.Duration
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("%s, retry after %s", ErrCircuitOpen, e.RetryAfter.Round(time.Millisecond))
+}
+
+func (e *CircuitOpenError) Unwrap() error {
+	return ErrCircuitOpen
+}
+
+// CircuitBreaker tracks failures and latency of gateway calls in a rolling window and
+// stops sending calls while the gateway is degraded.
+type CircuitBreaker struct {
+	mu  sync.Mutex
+	cfg config.CircuitBreaker
+	log logger.Logger
+
+	state    CircuitState
+	openedAt time.Time
+	buckets  [breakerBuckets]breakerBucket
+	probes   int
+	passed   int
+	// probedAt is when the first of the probe calls in flight was let through.
+	probedAt time.Time
+}
+
+type breakerBucket struct {
+	start    time.Time
+	total    int
+	failures int
+	slow     int
+}
+
+func NewCircuitBreaker(cfg config.CircuitBreaker, log logger.Logger) *CircuitBreaker {
+	if cfg.Window <= 0 {
+		cfg.Window = defaultBreakerWindow
+	}
+	if cfg.MinRequests <= 0 {
+		cfg.MinRequests = defaultBreakerMinRequests
+	}
+	if cfg.FailureRate <= 0 {
+		cfg.FailureRate = defaultBreakerFailureRate
+	}
+	if cfg.SlowCallDuration <= 0 {
+		cfg.SlowCallDuration = defaultBreakerSlowCallDuration
+	}
+	if cfg.SlowCallRate <= 0 {
+		cfg.SlowCallRate = defaultBreakerSlowCallRate
+	}
+	if cfg.OpenTimeout <= 0 {
+		cfg.OpenTimeout = defaultBreakerOpenTimeout
+	}
+	if cfg.HalfOpenRequests <= 0 {
+		cfg.HalfOpenRequests = defaultBreakerHalfOpenRequests
+	}
+
+	return &CircuitBreaker{
+		cfg:   cfg,
+		log:   log,
+		state: CircuitClosed,
+	}
+}
+
+// State returns the current state of the breaker.
+func (cb *CircuitBreaker) State() CircuitState {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if cb.state == CircuitOpen && time.Since(cb.openedAt) >= cb.cfg.OpenTimeout {
+		return CircuitHalfOpen
+	}
+	return cb.state
+}
+
+// allow decides whether a call may proceed. The returned function must be called with
+// the outcome of the call.
+func (cb *CircuitBreaker) allow(ctx context.Context) (func(callResult), error) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	now := time.Now()
+
+	if cb.state == CircuitOpen {
+		if wait := cb.cfg.OpenTimeout - now.Sub(cb.openedAt); wait > 0 {
+			return nil, &CircuitOpenError{RetryAfter: wait}
+		}
+		cb.transition(ctx, CircuitHalfOpen)
+	}
+
+	if cb.state == CircuitHalfOpen {
+		if cb.probes >= cb.cfg.HalfOpenRequests {
+			return nil, &CircuitOpenError{RetryAfter: cb.probeWait(now)}
+		}
+		if cb.probes == 0 {
+			cb.probedAt = now
+		}
+		cb.probes++
+		return func(r callResult) {
+			cb.probeDone(ctx, r)
+		}, nil
+	}
+
+	return func(r callResult) {
+		cb.record(ctx, r)
+	}, nil
+}
+
+// probeWait returns the time until the probe calls in flight are expected to decide
+// the state. A probe running longer than SlowCallDuration counts as slow and reopens
+// the breaker, the open timeout is returned then. It must be called with the mutex held.
+func (cb *CircuitBreaker) probeWait(now time.Time) time.Duration {
+	if wait := cb.cfg.SlowCallDuration - now.Sub(cb.probedAt); wait > 0 {
+		return wait
+	}
+	return cb.cfg.OpenTimeout
+}
+
+// callResult is the outcome of a call let through by the breaker.
+type callResult struct {
+	// ignored marks calls that say nothing about the gateway health.
+	ignored bool
+	failed  bool
+	latency time.Duration
+}
+
+func (cb *CircuitBreaker) probeDone(ctx context.Context, r callResult) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if cb.state != CircuitHalfOpen {
+		return
+	}
+	cb.probes--
+
+	if r.ignored {
+		return
+	}
+	if r.failed || r.latency >= cb.cfg.SlowCallDuration {
+		cb.transition(ctx, CircuitOpen)
+		return
+	}
+
+	cb.passed++
+	if cb.passed >= cb.cfg.HalfOpenRequests {
+		cb.transition(ctx, CircuitClosed)
+	}
+}
+
+func (cb *CircuitBreaker) record(ctx context.Context, r callResult) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if cb.state != CircuitClosed || r.ignored {
+		return
+	}
+
+	now := time.Now()
+	size := cb.cfg.Window / breakerBuckets
+	start := now.Truncate(size)
+	b := &cb.buckets[int(start.UnixNano()/int64(size))%breakerBuckets]
+	if !b.start.Equal(start) {
+		*b = breakerBucket{start: start}
+	}
+
+	b.total++
+	if r.failed {
+		b.failures++
+	}
+	if r.latency >= cb.cfg.SlowCallDuration {
+		b.slow++
+	}
+
+	var total, failures, slowCalls int
+	for _, bucket := range cb.buckets {
+		if now.Sub(bucket.start) < cb.cfg.Window {
+			total += bucket.total
+			failures += bucket.failures
+			slowCalls += bucket.slow
+		}
+	}
+
+	if total < cb.cfg.MinRequests {
+		return
+	}
+	if float64(failures)/float64(total) >= cb.cfg.FailureRate || float64(slowCalls)/float64(total) >= cb.cfg.SlowCallRate {
+		cb.log.Warn(ctx, "Circuit breaker: gateway degraded",
+			"requests", total,
+			"failures", failures,
+			"slow", slowCalls,
+		)
+		cb.transition(ctx, CircuitOpen)
+	}
+}
+
+// transition must be called with the mutex held.
+func (cb *CircuitBreaker) transition(ctx context.Context, to CircuitState) {
+	from := cb.state
+	cb.state = to
+	cb.probes = 0
+	cb.passed = 0
+
+	switch to {
+	case CircuitOpen:
+		cb.openedAt = time.Now()
+	case CircuitClosed:
+		cb.buckets = [breakerBuckets]breakerBucket{}
+	}
+
+	cb.log.Warn(ctx, "Circuit breaker state changed", "from", from, "to", to)
+}
+
+// CircuitBreakerRoundTripper rejects requests while the breaker is open and reports the
+// outcome of the others. Transport errors and 5xx responses count as failures, except
+// for authentication errors of the inner layers.
+type CircuitBreakerRoundTripper struct {
+	next    http.RoundTripper
+	breaker *CircuitBreaker
+}
+
+func NewCircuitBreakerTransport(next http.RoundTripper, breaker *CircuitBreaker) *CircuitBreakerRoundTripper {
+	return &CircuitBreakerRoundTripper{
+		next:    next,
+		breaker: breaker,
+	}
+}
+
+func (rt *CircuitBreakerRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	done, err := rt.breaker.allow(req.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	resp, err := rt.next.RoundTrip(req)
+
+	done(callResult{
+		ignored: err != nil && notGatewayError(req, err),
+		failed:  err != nil || resp.StatusCode >= 500,
+		latency: time.Since(start),
+	})
+	return resp, err
+}
+
+// notGatewayError reports errors which say nothing about the gateway health: calls
+// canceled by the caller and calls stopped before sending for lack of a token, e.g. a
+// scope the credentials are not granted or an outage of the token endpoint.
+func notGatewayError(req *http.Request, err error) bool {
+	var (
+		authErr  *AuthError
+		scopeErr *auth.ScopeError
+	)
+	return errors.Is(req.Context().Err(), context.Canceled) ||
+		errors.As(err, &authErr) ||
+		errors.As(err, &scopeErr)
+}
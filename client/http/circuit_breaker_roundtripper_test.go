@@ -0,0 +1,91 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tkliner/go-gopay/client/config"
+	"github.com/tkliner/go-gopay/client/logger"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	status := http.StatusInternalServerError
+	calls := 0
+	next := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		calls++
+		return &http.Response{StatusCode: status, Body: io.NopCloser(strings.NewReader(""))}, nil
+	})
+
+	breaker := NewCircuitBreaker(config.CircuitBreaker{
+		MinRequests: 4,
+		OpenTimeout: 50 * time.Millisecond,
+	}, logger.NewNoOpLogger())
+	rt := NewCircuitBreakerTransport(next, breaker)
+
+	do := func() error {
+		_, err := rt.RoundTrip(httptest.NewRequest(http.MethodGet, "https://gw.sandbox.gopay.com/api/payments/payment/1", nil))
+		return err
+	}
+
+	for i := 0; i < 4; i++ {
+		if err := do(); err != nil {
+			t.Fatalf("call %d: unexpected error %v", i, err)
+		}
+	}
+	if breaker.State() != CircuitOpen {
+		t.Fatalf("expected open breaker, got %s", breaker.State())
+	}
+
+	err := do()
+	var openErr *CircuitOpenError
+	if !errors.As(err, &openErr) || !errors.Is(err, ErrCircuitOpen) || calls != 4 {
+		t.Fatalf("expected fast failure, got %v after %d calls", err, calls)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if breaker.State() != CircuitHalfOpen {
+		t.Fatalf("expected half-open breaker, got %s", breaker.State())
+	}
+
+	status = http.StatusOK
+	if err := do(); err != nil {
+		t.Fatal(err)
+	}
+	if breaker.State() != CircuitClosed {
+		t.Errorf("expected closed breaker after successful probe, got %s", breaker.State())
+	}
+}
+
+func TestCircuitBreakerProbesInFlight(t *testing.T) {
+	breaker := NewCircuitBreaker(config.CircuitBreaker{
+		MinRequests:      1,
+		OpenTimeout:      time.Millisecond,
+		SlowCallDuration: time.Second,
+	}, logger.NewNoOpLogger())
+
+	done, _ := breaker.allow(context.Background())
+	done(callResult{failed: true})
+	time.Sleep(2 * time.Millisecond)
+
+	// The probe is let through, further calls wait for its outcome.
+	if _, err := breaker.allow(context.Background()); err != nil {
+		t.Fatalf("expected a probe call, got %v", err)
+	}
+	_, err := breaker.allow(context.Background())
+	var openErr *CircuitOpenError
+	if !errors.As(err, &openErr) || openErr.RetryAfter <= 0 || openErr.RetryAfter > time.Second {
+		t.Errorf("expected a retry hint within the probe window, got %v", err)
+	}
+}
@@ -1,8 +1,8 @@
 package http
 
 import (
+	"errors"
 	"net/http"
-	"time"
 
 	"github.com/tkliner/go-gopay/client/auth"
 	"github.com/tkliner/go-gopay/client/config"
@@ -11,34 +11,135 @@ import (
 	"github.com/tkliner/go-gopay/client/storage/inmemory"
 )
 
+// Stack is the authenticated HTTP client together with the parts of its transport
+// chain that the GoPay client needs to reach after construction.
+type Stack struct {
+	Client        *http.Client
+	Authenticator auth.Authenticator
+	// Breaker is nil unless a circuit breaker is configured.
+	Breaker *CircuitBreaker
+	// Limiter is nil unless rate limits are configured.
+	Limiter *RateLimiter
+	// Lifecycle tracks the requests in flight and closes the client.
+	Lifecycle *Lifecycle
 
-func NewHTTPClient(cfg *config.Config) (*http.Client, error) {
+	closeAuthenticator func()
+	transport          *http.Transport
+}
 
+// Close stops the authenticator and closes the idle connections of the transport
+// built by the stack. An authenticator set by config.WithAuthenticator and a
+// transport of the caller are left to their owners.
+func (s *Stack) Close() {
+	if s.closeAuthenticator != nil {
+		s.closeAuthenticator()
+	}
+	if s.transport != nil {
+		s.transport.CloseIdleConnections()
+	}
+}
+
+// NewStack builds the transport chain for the configuration on top of
+// http.DefaultTransport, see NewStackWithClient.
+func NewStack(cfg *config.Config) (*Stack, error) {
+	return NewStackWithClient(cfg, &http.Client{Timeout: cfg.Timeout})
+}
 
-	baseTransport := http.DefaultTransport
-	
+// NewStackWithClient builds the transport chain for the configuration on top of the
+// transport of c, keeping its timeout, cookie jar and redirect policy. A request
+// passes through the layers in this order:
+//
+//  1. lifecycle, rejecting calls after Close
+//  2. middlewares from config.WithMiddleware, the first one outermost
+//  3. metrics
+//  4. rate limiter
+//  5. circuit breaker
+//  6. authentication
+//  7. the transport of c, or one built from config.Transport, or http.DefaultTransport
+//
+// A retrying middleware thus passes every attempt through the rate limiter, the
+// circuit breaker and authentication, and a logging middleware never sees the
+// Authorization header. Token requests use the base transport directly.
+func NewStackWithClient(cfg *config.Config, c *http.Client) (*Stack, error) {
+	stack := &Stack{Lifecycle: NewLifecycle()}
+
+	var baseTransport http.RoundTripper = c.Transport
+	switch {
+	case baseTransport != nil && cfg.Transport != nil:
+		return nil, errors.New("config.Transport cannot be combined with a client having its own transport")
+	case cfg.Transport != nil:
+		transport, err := NewTransport(*cfg.Transport)
+		if err != nil {
+			return nil, err
+		}
+		stack.transport = transport
+		baseTransport = transport
+	case baseTransport == nil:
+		baseTransport = http.DefaultTransport
+	}
+	tokenTransport := baseTransport
+
+	timeout := cfg.Timeout
+	if timeout == 0 {
+		timeout = config.DefaultTimeout
+	}
 	httpClient := &http.Client{
-		Timeout: 30 * time.Second,
+		Transport: tokenTransport,
+		Timeout:   timeout,
 	}
 
-	tokenStorage := newTokenStorage(cfg)
-	authenticator := newAuthenticator(cfg, tokenStorage, httpClient, cfg.Logger)
+	var authenticator auth.Authenticator
+	if cfg.Authenticator != nil {
+		authenticator = auth.FromTokenSource(cfg.Authenticator)
+	} else {
+		tokenStorage := newTokenStorage(cfg)
+		gopayAuthenticator := newAuthenticator(cfg, tokenStorage, httpClient, cfg.Logger)
+		stack.closeAuthenticator = gopayAuthenticator.Close
+		authenticator = gopayAuthenticator
+	}
+	stack.Authenticator = authenticator
 
 	authTransport := newAuthTransport(authenticator)
 	authTransport.next = baseTransport
 
 	var finalTransport http.RoundTripper = authTransport
+
+	if cfg.CircuitBreaker != nil {
+		stack.Breaker = NewCircuitBreaker(*cfg.CircuitBreaker, cfg.Logger)
+		finalTransport = NewCircuitBreakerTransport(finalTransport, stack.Breaker)
+	}
+
+	if cfg.RateLimits != nil {
+		stack.Limiter = NewRateLimiter(*cfg.RateLimits, cfg.Logger)
+		finalTransport = NewRateLimitTransport(finalTransport, stack.Limiter)
+		httpClient.Transport = NewRateLimitTransport(tokenTransport, stack.Limiter)
+	}
+
 	if cfg.EnableMetrics {
-		metricsTransport := NewMetricsTransport(authTransport, cfg.Logger)
+		metricsTransport := NewMetricsTransport(finalTransport, cfg.Logger)
 		finalTransport = metricsTransport
 	}
 
-	finalHTTPClient := &http.Client{
-		Transport: finalTransport,
-		Timeout:   cfg.Timeout,
+	for i := len(cfg.Middleware) - 1; i >= 0; i-- {
+		finalTransport = cfg.Middleware[i](finalTransport)
+	}
+
+	finalTransport = NewLifecycleTransport(finalTransport, stack.Lifecycle)
+
+	client := *c
+	client.Transport = finalTransport
+	stack.Client = &client
+
+	return stack, nil
+}
+
+func NewHTTPClient(cfg *config.Config) (*http.Client, error) {
+	stack, err := NewStack(cfg)
+	if err != nil {
+		return nil, err
 	}
 
-	return finalHTTPClient, nil
+	return stack.Client, nil
 }
 
 func newTokenStorage(cfg *config.Config) storage.TokenStorage {
@@ -63,4 +164,4 @@ func newAuthTransport(authenticator auth.Authenticator) *AuthRoundTripper {
 		next:          http.DefaultTransport,
 		authenticator: authenticator,
 	}
-}
\ No newline at end of file
+}
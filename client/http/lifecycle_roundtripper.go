@@ -0,0 +1,131 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrClosed is returned for calls made after the client was closed.
+var ErrClosed = errors.New("gopay client is closed")
+
+// Lifecycle counts requests in flight and rejects new ones once closed. A request
+// stays in flight until its response body is closed. It also records the time of
+// the last successful call.
+type Lifecycle struct {
+	mu       sync.Mutex
+	closed   bool
+	inFlight int
+	idle     chan struct{}
+
+	lastSuccess atomic.Int64
+}
+
+func NewLifecycle() *Lifecycle {
+	return &Lifecycle{idle: make(chan struct{})}
+}
+
+func (l *Lifecycle) acquire() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.closed {
+		return ErrClosed
+	}
+	l.inFlight++
+	return nil
+}
+
+func (l *Lifecycle) release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.inFlight--
+	if l.closed && l.inFlight == 0 {
+		close(l.idle)
+	}
+}
+
+// LastSuccess returns the time of the last call answered with a 2xx status, or
+// the zero time if there was none.
+func (l *Lifecycle) LastSuccess() time.Time {
+	if ns := l.lastSuccess.Load(); ns != 0 {
+		return time.Unix(0, ns)
+	}
+	return time.Time{}
+}
+
+// Closed reports whether Close was called.
+func (l *Lifecycle) Closed() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.closed
+}
+
+// Close rejects new requests and waits until the requests in flight finish or
+// ctx is done. Calling Close again waits for the same requests.
+func (l *Lifecycle) Close(ctx context.Context) error {
+	l.mu.Lock()
+	if !l.closed {
+		l.closed = true
+		if l.inFlight == 0 {
+			close(l.idle)
+		}
+	}
+	l.mu.Unlock()
+
+	select {
+	case <-l.idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// LifecycleRoundTripper tracks requests in a Lifecycle.
+type LifecycleRoundTripper struct {
+	next      http.RoundTripper
+	lifecycle *Lifecycle
+}
+
+func NewLifecycleTransport(next http.RoundTripper, lifecycle *Lifecycle) *LifecycleRoundTripper {
+	return &LifecycleRoundTripper{
+		next:      next,
+		lifecycle: lifecycle,
+	}
+}
+
+func (rt *LifecycleRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := rt.lifecycle.acquire(); err != nil {
+		return nil, err
+	}
+
+	resp, err := rt.next.RoundTrip(req)
+	if err == nil && resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		rt.lifecycle.lastSuccess.Store(time.Now().UnixNano())
+	}
+	if err != nil || resp.Body == nil {
+		rt.lifecycle.release()
+		return resp, err
+	}
+
+	resp.Body = &releasingBody{ReadCloser: resp.Body, release: rt.lifecycle.release}
+	return resp, nil
+}
+
+// releasingBody ends the request in flight when the body is closed.
+type releasingBody struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+func (b *releasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
@@ -0,0 +1,234 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tkliner/go-gopay/client/config"
+	"github.com/tkliner/go-gopay/client/logger"
+)
+
+// EndpointClass groups GoPay endpoints sharing one rate limit budget.
+type EndpointClass string
+
+const (
+	EndpointToken         EndpointClass = "token"
+	EndpointPaymentRead   EndpointClass = "payment_read"
+	EndpointPaymentCreate EndpointClass = "payment_create"
+	EndpointRefund        EndpointClass = "refund"
+)
+
+// ClassifyRequest returns the endpoint class of the request.
+func ClassifyRequest(req *http.Request) EndpointClass {
+	path := strings.TrimSuffix(req.URL.Path, "/")
+
+	switch {
+	case strings.HasSuffix(path, "/oauth2/token"):
+		return EndpointToken
+	case strings.HasSuffix(path, "/refund"):
+		return EndpointRefund
+	case req.Method == http.MethodGet || req.Method == http.MethodHead:
+		return EndpointPaymentRead
+	}
+	return EndpointPaymentCreate
+}
+
+// RateLimitStats describes the current load of one endpoint class.
+type RateLimitStats struct {
+	// QueueDepth is the number of requests currently waiting for the budget.
+	QueueDepth int
+	// Waits is the number of requests that had to wait since the limiter was created.
+	Waits int64
+	// TotalWait is the time all requests spent waiting.
+	TotalWait time.Duration
+	// LastWait is the wait time of the most recent delayed request.
+	LastWait time.Duration
+}
+
+// RateLimiter holds the token buckets of all endpoint classes. It is shared by the API
+// and token transports so that one budget applies per client.
+type RateLimiter struct {
+	buckets map[EndpointClass]*tokenBucket
+	log     logger.Logger
+}
+
+// NewRateLimiter creates a limiter for the given budgets. Classes with zero rate are not limited.
+func NewRateLimiter(limits config.RateLimits, log logger.Logger) *RateLimiter {
+	l := &RateLimiter{
+		buckets: make(map[EndpointClass]*tokenBucket),
+		log:     log,
+	}
+
+	for class, limit := range map[EndpointClass]config.RateLimit{
+		EndpointToken:         limits.Token,
+		EndpointPaymentRead:   limits.PaymentRead,
+		EndpointPaymentCreate: limits.PaymentCreate,
+		EndpointRefund:        limits.Refund,
+	} {
+		if limit.Rate > 0 {
+			l.buckets[class] = newTokenBucket(limit.Rate, limit.Burst)
+		}
+	}
+
+	return l
+}
+
+// Wait blocks until a request of the class fits into its budget or ctx is done.
+func (l *RateLimiter) Wait(ctx context.Context, class EndpointClass) error {
+	b, ok := l.buckets[class]
+	if !ok {
+		return nil
+	}
+
+	delay := b.reserve(time.Now())
+	if delay <= 0 {
+		return nil
+	}
+
+	depth := b.enqueue()
+	l.log.Debug(ctx, "Rate limit: waiting for budget",
+		"class", class,
+		"queue_depth", depth,
+		"wait_ms", delay.Milliseconds(),
+	)
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		b.dequeue(delay)
+		return nil
+	case <-ctx.Done():
+		b.cancel()
+		b.dequeue(0)
+		return ctx.Err()
+	}
+}
+
+// Stats returns the current statistics of all limited endpoint classes.
+func (l *RateLimiter) Stats() map[EndpointClass]RateLimitStats {
+	stats := make(map[EndpointClass]RateLimitStats, len(l.buckets))
+	for class, b := range l.buckets {
+		stats[class] = b.stats()
+	}
+	return stats
+}
+
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+
+	queue     int
+	waits     int64
+	totalWait time.Duration
+	lastWait  time.Duration
+}
+
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	b := math.Max(float64(burst), 1)
+	return &tokenBucket{
+		rate:   rate,
+		burst:  b,
+		tokens: b,
+		last:   time.Now(),
+	}
+}
+
+// reserve takes one token and returns how long the caller has to wait for it.
+// Tokens may go negative, which queues callers in the order they arrived.
+func (b *tokenBucket) reserve(now time.Time) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = math.Min(b.burst, b.tokens+elapsed.Seconds()*b.rate)
+		b.last = now
+	}
+
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// cancel returns a token taken by a caller that gave up waiting.
+func (b *tokenBucket) cancel() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens = math.Min(b.burst, b.tokens+1)
+}
+
+func (b *tokenBucket) enqueue() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.queue++
+	return b.queue
+}
+
+func (b *tokenBucket) dequeue(waited time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.queue--
+	if waited > 0 {
+		b.waits++
+		b.totalWait += waited
+		b.lastWait = waited
+	}
+}
+
+func (b *tokenBucket) stats() RateLimitStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return RateLimitStats{
+		QueueDepth: b.queue,
+		Waits:      b.waits,
+		TotalWait:  b.totalWait,
+		LastWait:   b.lastWait,
+	}
+}
+
+// RateLimitRoundTripper delays requests that exceed the budget of their endpoint class.
+type RateLimitRoundTripper struct {
+	next    http.RoundTripper
+	limiter *RateLimiter
+}
+
+func NewRateLimitTransport(next http.RoundTripper, limiter *RateLimiter) *RateLimitRoundTripper {
+	return &RateLimitRoundTripper{
+		next:    next,
+		limiter: limiter,
+	}
+}
+
+func (rt *RateLimitRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	class := ClassifyRequest(req)
+	if err := rt.limiter.Wait(req.Context(), class); err != nil {
+		return nil, &RateLimitWaitError{Class: class, Err: err}
+	}
+	return rt.next.RoundTrip(req)
+}
+
+// RateLimitWaitError is returned when the context is done while the request waits for
+// the budget of its endpoint class. The request was not sent.
+type RateLimitWaitError struct {
+	Class EndpointClass
+	Err   error
+}
+
+func (e *RateLimitWaitError) Error() string {
+	return fmt.Sprintf("rate limit: stopped waiting for %s budget: %v", e.Class, e.Err)
+}
+
+func (e *RateLimitWaitError) Unwrap() error {
+	return e.Err
+}
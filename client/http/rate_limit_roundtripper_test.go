@@ -0,0 +1,84 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/tkliner/go-gopay/client/config"
+	"github.com/tkliner/go-gopay/client/logger"
+)
+
+func TestClassifyRequest(t *testing.T) {
+	tests := []struct {
+		method, path string
+		want         EndpointClass
+	}{
+		{http.MethodPost, "/api/oauth2/token", EndpointToken},
+		{http.MethodGet, "/api/payments/payment/123", EndpointPaymentRead},
+		{http.MethodPost, "/api/payments/payment", EndpointPaymentCreate},
+		{http.MethodPost, "/api/payments/payment/123/refund", EndpointRefund},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, "https://gw.sandbox.gopay.com"+tt.path, nil)
+		if got := ClassifyRequest(req); got != tt.want {
+			t.Errorf("ClassifyRequest(%s %s) = %s, want %s", tt.method, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestRateLimiterWait(t *testing.T) {
+	limiter := NewRateLimiter(config.RateLimits{
+		PaymentRead: config.RateLimit{Rate: 50, Burst: 1},
+	}, logger.NewNoOpLogger())
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := limiter.Wait(context.Background(), EndpointPaymentRead); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 35*time.Millisecond {
+		t.Errorf("expected requests to be delayed, took %s", elapsed)
+	}
+
+	stats := limiter.Stats()[EndpointPaymentRead]
+	if stats.Waits != 2 || stats.QueueDepth != 0 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+
+	// Classes without a budget are not limited.
+	if err := limiter.Wait(context.Background(), EndpointRefund); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	limiter.Wait(context.Background(), EndpointPaymentRead)
+	if err := limiter.Wait(ctx, EndpointPaymentRead); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected context deadline, got %v", err)
+	}
+}
+
+func TestRateLimitTransportWaitError(t *testing.T) {
+	limiter := NewRateLimiter(config.RateLimits{
+		PaymentRead: config.RateLimit{Rate: 1, Burst: 1},
+	}, logger.NewNoOpLogger())
+	limiter.Wait(context.Background(), EndpointPaymentRead)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+
+	rt := NewRateLimitTransport(http.DefaultTransport, limiter)
+	req := httptest.NewRequest(http.MethodGet, "https://gw.sandbox.gopay.com/api/payments/payment/1", nil).WithContext(ctx)
+
+	_, err := rt.RoundTrip(req)
+	var waitErr *RateLimitWaitError
+	if !errors.As(err, &waitErr) || waitErr.Class != EndpointPaymentRead || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected RateLimitWaitError with the deadline, got %v", err)
+	}
+}
@@ -0,0 +1,79 @@
+package http
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/tkliner/go-gopay/client/config"
+)
+
+const (
+	defaultMaxIdleConns        = 100
+	defaultMaxIdleConnsPerHost = 10
+	defaultIdleConnTimeout     = 90 * time.Second
+	defaultKeepAlive           = 30 * time.Second
+	defaultTLSHandshakeTimeout = 10 * time.Second
+	defaultDialTimeout         = 30 * time.Second
+)
+
+// NewTransport builds the transport to the gateway from the configuration.
+func NewTransport(cfg config.Transport) (*http.Transport, error) {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+
+	if cfg.ProxyURL != "" {
+		proxy, err := url.Parse(cfg.ProxyURL)
+		if err != nil || proxy.Host == "" {
+			return nil, fmt.Errorf("invalid proxy URL %q", cfg.ProxyURL)
+		}
+		t.Proxy = http.ProxyURL(proxy)
+	}
+
+	keepAlive := orDuration(cfg.KeepAlive, defaultKeepAlive)
+	t.DialContext = (&net.Dialer{
+		Timeout:   defaultDialTimeout,
+		KeepAlive: keepAlive,
+	}).DialContext
+
+	minVersion := cfg.MinTLSVersion
+	if minVersion == 0 {
+		minVersion = tls.VersionTLS12
+	}
+	t.TLSClientConfig = &tls.Config{
+		RootCAs:      cfg.RootCAs,
+		Certificates: cfg.Certificates,
+		MinVersion:   minVersion,
+	}
+
+	if cfg.DisableHTTP2 {
+		protocols := new(http.Protocols)
+		protocols.SetHTTP1(true)
+		t.Protocols = protocols
+		t.ForceAttemptHTTP2 = false
+	}
+
+	t.MaxIdleConns = orInt(cfg.MaxIdleConns, defaultMaxIdleConns)
+	t.MaxIdleConnsPerHost = orInt(cfg.MaxIdleConnsPerHost, defaultMaxIdleConnsPerHost)
+	t.MaxConnsPerHost = cfg.MaxConnsPerHost
+	t.IdleConnTimeout = orDuration(cfg.IdleConnTimeout, defaultIdleConnTimeout)
+	t.TLSHandshakeTimeout = orDuration(cfg.TLSHandshakeTimeout, defaultTLSHandshakeTimeout)
+
+	return t, nil
+}
+
+func orInt(v, def int) int {
+	if v == 0 {
+		return def
+	}
+	return v
+}
+
+func orDuration(v, def time.Duration) time.Duration {
+	if v == 0 {
+		return def
+	}
+	return v
+}
@@ -0,0 +1,177 @@
+package http
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/tkliner/go-gopay/client/config"
+	"github.com/tkliner/go-gopay/client/logger"
+)
+
+// gatewayHandler answers token requests and reports the protocol of API requests.
+func gatewayHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if r.URL.Path == "/api/oauth2/token" {
+		w.Write([]byte(`{"access_token":"token","expires_in":1800}`))
+		return
+	}
+	w.Header().Set("X-Proto", r.Proto)
+}
+
+func getThroughStack(t *testing.T, gatewayURL string, transport config.Transport) (*http.Response, error) {
+	t.Helper()
+
+	cfg := config.NewConfig(
+		config.WithGatewayURL(gatewayURL),
+		config.WithCredentials(8123456789, "client", "secret"),
+		config.WithLogger(logger.NewNoOpLogger()),
+		config.WithTransport(transport),
+	)
+	stack, err := NewStack(cfg)
+	if err != nil {
+		t.Fatalf("NewStack: %v", err)
+	}
+	t.Cleanup(stack.Close)
+
+	resp, err := stack.Client.Get(gatewayURL + "/api/payments/payment/1")
+	if err == nil {
+		resp.Body.Close()
+	}
+	return resp, err
+}
+
+func certPool(srv *httptest.Server) *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(srv.Certificate())
+	return pool
+}
+
+func TestTransportRootCAs(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(gatewayHandler))
+	defer srv.Close()
+
+	if _, err := getThroughStack(t, srv.URL, config.Transport{}); err == nil {
+		t.Fatal("expected certificate error with system roots")
+	}
+	if _, err := getThroughStack(t, srv.URL, config.Transport{RootCAs: certPool(srv)}); err != nil {
+		t.Fatalf("request with pinned CA failed: %v", err)
+	}
+}
+
+func TestTransportMinTLSVersion(t *testing.T) {
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(gatewayHandler))
+	srv.TLS = &tls.Config{MaxVersion: tls.VersionTLS12}
+	srv.StartTLS()
+	defer srv.Close()
+
+	_, err := getThroughStack(t, srv.URL, config.Transport{RootCAs: certPool(srv), MinTLSVersion: tls.VersionTLS13})
+	if err == nil {
+		t.Fatal("expected handshake failure")
+	}
+}
+
+func TestTransportHTTP2(t *testing.T) {
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(gatewayHandler))
+	srv.EnableHTTP2 = true
+	srv.StartTLS()
+	defer srv.Close()
+
+	for _, tt := range []struct {
+		disable bool
+		want    string
+	}{{false, "HTTP/2.0"}, {true, "HTTP/1.1"}} {
+		resp, err := getThroughStack(t, srv.URL, config.Transport{RootCAs: certPool(srv), DisableHTTP2: tt.disable})
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		if got := resp.Header.Get("X-Proto"); got != tt.want {
+			t.Errorf("DisableHTTP2=%v: got %s, want %s", tt.disable, got, tt.want)
+		}
+	}
+}
+
+func TestTransportClientCertificate(t *testing.T) {
+	cert := newClientCertificate(t)
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(cert.Leaf)
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(gatewayHandler))
+	srv.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	srv.StartTLS()
+	defer srv.Close()
+
+	if _, err := getThroughStack(t, srv.URL, config.Transport{RootCAs: certPool(srv)}); err == nil {
+		t.Fatal("expected failure without client certificate")
+	}
+	if _, err := getThroughStack(t, srv.URL, config.Transport{RootCAs: certPool(srv), Certificates: []tls.Certificate{cert}}); err != nil {
+		t.Fatalf("request with client certificate failed: %v", err)
+	}
+}
+
+func TestTransportProxy(t *testing.T) {
+	var proxied []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = append(proxied, r.URL.Path)
+		gatewayHandler(w, r)
+	}))
+	defer proxy.Close()
+
+	// The gateway is never contacted directly, the proxy answers for it.
+	if _, err := getThroughStack(t, "http://gateway.invalid", config.Transport{ProxyURL: proxy.URL}); err != nil {
+		t.Fatalf("request through proxy failed: %v", err)
+	}
+	if len(proxied) != 2 || proxied[0] != "/api/oauth2/token" {
+		t.Errorf("token and API requests not proxied: %v", proxied)
+	}
+}
+
+func TestTransportConflictsWithClientTransport(t *testing.T) {
+	cfg := config.NewConfig(config.WithTransport(config.Transport{}))
+
+	if _, err := NewStackWithClient(cfg, &http.Client{Transport: http.DefaultTransport}); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestNewTransportInvalidProxy(t *testing.T) {
+	if _, err := NewTransport(config.Transport{ProxyURL: "://"}); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func newClientCertificate(t *testing.T) tls.Certificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "eshop"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}
+}
@@ -1,14 +1,19 @@
 package client
 
 import (
+	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"path"
+	"regexp"
+	"strings"
+	"time"
 
+	"github.com/tkliner/go-gopay/client/auth"
+	"github.com/tkliner/go-gopay/client/config"
 	"github.com/tkliner/go-gopay/client/logger"
 )
 
@@ -22,7 +27,18 @@ type Request struct {
 	resource   string
 	method     string
 
-	body io.Reader
+	pathParams map[string]string
+	params     url.Values
+	timeout    time.Duration
+	scope      config.TokenScope
+
+	body        io.Reader
+	contentType string
+	accept      string
+	headers     http.Header
+
+	// err holds the first error of the builder, it is returned by Do.
+	err error
 
 	logger logger.Logger
 
@@ -51,73 +67,276 @@ func (r *Request) Method(verb string) *Request {
 	return r
 }
 
+// Resource sets the path of the resource below the API prefix. It may contain
+// {name} placeholders which are replaced by escaped values set by PathParam.
 func (r *Request) Resource(resource string) *Request {
 	r.resource = resource
 	return r
 }
 
+// PathParam sets the value of the {name} placeholder of the resource path.
+func (r *Request) PathParam(name string, value any) *Request {
+	if r.pathParams == nil {
+		r.pathParams = map[string]string{}
+	}
+	r.pathParams[name] = fmt.Sprint(value)
+	return r
+}
+
+// Param adds a query parameter.
+func (r *Request) Param(key, value string) *Request {
+	if r.params == nil {
+		r.params = url.Values{}
+	}
+	r.params.Add(key, value)
+	return r
+}
+
+// Timeout limits the duration of this request, in addition to the client timeout.
+func (r *Request) Timeout(d time.Duration) *Request {
+	r.timeout = d
+	return r
+}
+
+// Scope sets the token scope required by the request, see auth.WithScope.
+func (r *Request) Scope(scope config.TokenScope) *Request {
+	r.scope = scope
+	return r
+}
+
+// SetHeader sets a request header, replacing the default one of the same name.
+func (r *Request) SetHeader(key, value string) *Request {
+	if r.headers == nil {
+		r.headers = http.Header{}
+	}
+	r.headers.Set(key, value)
+	return r
+}
+
+// AddHeader adds a value to a request header.
+func (r *Request) AddHeader(key, value string) *Request {
+	if r.headers == nil {
+		r.headers = http.Header{}
+	}
+	r.headers.Add(key, value)
+	return r
+}
+
+// Body sets the request body as is, e.g. binary data. It is sent with the
+// client content type unless ContentType or a Content-Type header is set.
+func (r *Request) Body(body io.Reader) *Request {
+	r.body = body
+	return r
+}
+
+// ContentType sets the media type of the request. It selects the serializer
+// used by Encode and is sent in the Content-Type header even without a body.
+func (r *Request) ContentType(contentType string) *Request {
+	r.contentType = contentType
+	return r
+}
+
+// Accept sets the media types accepted in the response.
+func (r *Request) Accept(contentTypes ...string) *Request {
+	r.accept = strings.Join(contentTypes, ", ")
+	return r
+}
+
+// Encode sets v as the request body, encoded by the serializer of the request
+// content type, or of the client content type if none is set.
+func (r *Request) Encode(v any) *Request {
+	contentType := r.contentType
+	if contentType == "" {
+		contentType = r.c.content.ContentType
+	}
+
+	s, ok := r.c.serializer(contentType)
+	if !ok {
+		r.setErr(fmt.Errorf("no serializer for content type %q", contentType))
+		return r
+	}
+
+	data, err := s.Encode(v)
+	if err != nil {
+		r.setErr(fmt.Errorf("failed to encode request body: %w", err))
+		return r
+	}
+	r.body = bytes.NewReader(data)
+	r.contentType = contentType
+	return r
+}
+
+// JSONBody sets the JSON encoding of v as the request body.
+func (r *Request) JSONBody(v any) *Request {
+	return r.ContentType(ContentTypeJSON).Encode(v)
+}
+
+// FormBody sets the URL encoded form as the request body.
+func (r *Request) FormBody(values url.Values) *Request {
+	return r.ContentType(ContentTypeForm).Encode(values)
+}
+
+func (r *Request) setErr(err error) {
+	if r.err == nil {
+		r.err = err
+	}
+}
+
 func (r *Request) Do(ctx context.Context) Result {
 	var result Result
 
+	start := time.Now()
 	err := r.request(ctx, func(req *http.Request, resp *http.Response) {
-		result =  r.processResponse(resp, req)
+		result = r.processResponse(resp, req)
+		result.header = resp.Header
+		result.latency = time.Since(start)
 	})
 
 	if err != nil {
-		fmt.Println("Error during request:", err)
+		r.logger.Error(ctx, "Error during request", "error", err)
+		return Result{err: err}
 	}
 
 	return result
 }
 
 func (r *Request) request(ctx context.Context, fn func(*http.Request, *http.Response)) error {
-	client := r.c.client
-
-	req, err := r.newHTTPRequest(ctx)
+	resp, err := r.DoRaw(ctx)
 	if err != nil {
 		return err
 	}
-
-	resp, err := client.Do(req)
-	if err != nil {
-		return fmt.Errorf("request failed: %w", err)
-	}
 	defer resp.Body.Close()
-	
+
 	if fn != nil {
-		fn(req, resp)
+		fn(resp.Request, resp)
 	}
 
 	return nil
 }
 
+// DoRaw sends the request and returns the response as is, whatever its status
+// code. The caller must close the response body.
+func (r *Request) DoRaw(ctx context.Context) (*http.Response, error) {
+	if r.err != nil {
+		return nil, &BuildError{Err: r.err}
+	}
+
+	cancel := context.CancelFunc(func() {})
+	if r.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, r.timeout)
+	}
+
+	if r.scope != "" {
+		ctx = auth.WithScope(ctx, r.scope)
+	}
+
+	req, err := r.newHTTPRequest(ctx)
+	if err != nil {
+		cancel()
+		return nil, &BuildError{Err: err}
+	}
+
+	resp, err := r.c.client.Do(req)
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+
+	resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// cancelBody releases the request timeout when the body is closed.
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
 func (r *Request) newHTTPRequest(ctx context.Context) (*http.Request, error) {
 	var body io.Reader
 
 	body = r.body
 
-	url := r.URL().String()
-	req, err := http.NewRequestWithContext(ctx, r.method, url, body)
+	u, err := r.buildURL()
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, r.method, u.String(), body)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create new HTTP request: %w", err)
 	}
 
+	if r.contentType != "" {
+		req.Header.Set("Content-Type", r.contentType)
+	} else if body != nil {
+		req.Header.Set("Content-Type", r.c.content.ContentType)
+	}
+
+	accept := r.accept
+	if accept == "" {
+		accept = r.c.content.AcceptContentTypes
+	}
+	req.Header.Set("Accept", accept)
+
+	for key, values := range r.headers {
+		req.Header[key] = values
+	}
+
 	return req, nil
 
 }
+
+// URL returns the URL of the request. Placeholders without a value are left as they are.
 func (r *Request) URL() *url.URL {
+	u, _ := r.buildURL()
+	return u
+}
+
+var pathPlaceholder = regexp.MustCompile(`\{([^{}/]+)\}`)
+
+func (r *Request) buildURL() (*url.URL, error) {
 	p := r.pathPrefix
 
 	if len(r.resource) != 0 {
 		p = path.Join(p, r.resource)
 	}
 
+	var err error
+	expand := func(escape func(string) string) string {
+		return pathPlaceholder.ReplaceAllStringFunc(p, func(placeholder string) string {
+			name := placeholder[1 : len(placeholder)-1]
+			value, ok := r.pathParams[name]
+			switch {
+			case !ok:
+				err = fmt.Errorf("missing value of path parameter %q", name)
+				return placeholder
+			case value == "" || value == "." || value == "..":
+				err = fmt.Errorf("invalid value %q of path parameter %q", value, name)
+				return placeholder
+			}
+			return escape(value)
+		})
+	}
+
 	finalURL := &url.URL{}
 	if r.c.base != nil {
 		*finalURL = *r.c.base
 	}
-	finalURL.Path = p
-	return finalURL
+	finalURL.Path = expand(func(v string) string { return v })
+	if raw := expand(url.PathEscape); raw != finalURL.Path {
+		finalURL.RawPath = raw
+	}
+	if len(r.params) > 0 {
+		finalURL.RawQuery = r.params.Encode()
+	}
+	return finalURL, err
 }
 
 func (r *Request) processResponse(resp *http.Response, req *http.Request) Result {
@@ -135,13 +354,42 @@ func (r *Request) processResponse(resp *http.Response, req *http.Request) Result
 	contentType := resp.Header.Get("Content-Type")
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		err := fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(body))
+		err := &StatusError{StatusCode: resp.StatusCode, Body: body}
 		r.logger.Error(req.Context(), "Request failed", "status", resp.StatusCode, "body", string(body))
 		return Result{body: body, contentType: contentType, err: err, statusCode: resp.StatusCode}
 	}
 
+	decoder, ok := r.c.serializer(contentType)
+	if !ok {
+		decoder, _ = r.c.serializer(r.c.content.ContentType)
+	}
+
 	r.logger.Info(req.Context(), "Request successful", "status", resp.StatusCode)
-	return Result{body: body, contentType: contentType, statusCode: resp.StatusCode}
+	return Result{body: body, contentType: contentType, statusCode: resp.StatusCode, decoder: decoder, strict: r.c.strict}
+}
+
+// BuildError is returned when the request could not be built, e.g. a missing path
+// parameter or a body that failed to encode. The request was not sent.
+type BuildError struct {
+	Err error
+}
+
+func (e *BuildError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *BuildError) Unwrap() error {
+	return e.Err
+}
+
+// StatusError is returned when GoPay answers with a non 2xx status code.
+type StatusError struct {
+	StatusCode int
+	Body       []byte
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("HTTP %d: %s", e.StatusCode, string(e.Body))
 }
 
 type Result struct {
@@ -149,9 +397,57 @@ type Result struct {
 	contentType string
 	err         error
 	statusCode  int
+	header      http.Header
+	latency     time.Duration
+	decoder     Serializer
+	strict      bool
+}
+
+// Error returns the error of the request, either a transport failure or a non 2xx response.
+func (r Result) Error() error {
+	return r.err
+}
+
+// ContentType returns the Content-Type header of the response.
+func (r Result) ContentType() string {
+	return r.contentType
+}
+
+// Raw returns the response body as is, e.g. for file downloads.
+func (r Result) Raw() ([]byte, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	return r.body, nil
 }
 
+// Convert decodes the response body into obj with the serializer of the
+// response content type, falling back to the client content type. A *[]byte
+// or io.Writer receives the body as is. The error of a failed request is returned
+// without touching obj.
 func (r Result) Convert(obj any) error {
-	if len(r.body) > 0 {return json.Unmarshal(r.body, obj)}
+	if r.err != nil {
+		return r.err
+	}
+	switch target := obj.(type) {
+	case *[]byte:
+		*target = r.body
+		return nil
+	case io.Writer:
+		_, err := target.Write(r.body)
+		return err
+	}
+	if len(r.body) == 0 {
+		return nil
+	}
+	if r.decoder == nil {
+		return fmt.Errorf("no serializer for content type %q", r.contentType)
+	}
+	if err := r.decoder.Decode(r.body, obj); err != nil {
+		return err
+	}
+	if r.strict && r.decoder.ContentType() == ContentTypeJSON {
+		return checkUnknownFields(r.body, obj)
+	}
 	return nil
-}
\ No newline at end of file
+}
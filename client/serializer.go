@@ -0,0 +1,133 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/url"
+	"reflect"
+	"strings"
+
+	"github.com/tkliner/go-gopay/client/config"
+)
+
+const (
+	ContentTypeJSON        = "application/json"
+	ContentTypeForm        = "application/x-www-form-urlencoded"
+	ContentTypeOctetStream = "application/octet-stream"
+)
+
+// Serializer encodes request bodies and decodes response bodies of one media type.
+type Serializer = config.Serializer
+
+// JSONSerializer handles application/json.
+type JSONSerializer struct{}
+
+func (JSONSerializer) ContentType() string { return ContentTypeJSON }
+
+func (JSONSerializer) Encode(v any) ([]byte, error) { return json.Marshal(v) }
+
+func (JSONSerializer) Decode(data []byte, v any) error { return json.Unmarshal(data, v) }
+
+// FormSerializer handles application/x-www-form-urlencoded. It encodes url.Values,
+// map[string]string and structs, whose exported fields are named by their json tags.
+// It decodes into *url.Values and *map[string]string.
+type FormSerializer struct{}
+
+func (FormSerializer) ContentType() string { return ContentTypeForm }
+
+func (FormSerializer) Encode(v any) ([]byte, error) {
+	values, err := formValues(v)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(values.Encode()), nil
+}
+
+func (FormSerializer) Decode(data []byte, v any) error {
+	values, err := url.ParseQuery(string(data))
+	if err != nil {
+		return err
+	}
+	switch target := v.(type) {
+	case *url.Values:
+		*target = values
+	case *map[string]string:
+		m := make(map[string]string, len(values))
+		for k := range values {
+			m[k] = values.Get(k)
+		}
+		*target = m
+	default:
+		return fmt.Errorf("cannot decode form into %T", v)
+	}
+	return nil
+}
+
+func formValues(v any) (url.Values, error) {
+	switch v := v.(type) {
+	case url.Values:
+		return v, nil
+	case map[string]string:
+		values := url.Values{}
+		for k, s := range v {
+			values.Set(k, s)
+		}
+		return values, nil
+	}
+
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return url.Values{}, nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("cannot encode %T as form", v)
+	}
+
+	values := url.Values{}
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		f := rt.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fv := rv.Field(i)
+		if strings.Contains(opts, "omitempty") && fv.IsZero() {
+			continue
+		}
+		values.Set(name, fmt.Sprint(fv.Interface()))
+	}
+	return values, nil
+}
+
+// RegisterSerializer adds or replaces the serializer for its content type.
+func (c *Client) RegisterSerializer(s Serializer) {
+	c.serializers[s.ContentType()] = s
+}
+
+// serializer returns the serializer of the media type, ignoring its parameters.
+func (c *Client) serializer(contentType string) (Serializer, bool) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, false
+	}
+	s, ok := c.serializers[mediaType]
+	return s, ok
+}
+
+func defaultSerializers() map[string]Serializer {
+	return map[string]Serializer{
+		ContentTypeJSON: JSONSerializer{},
+		ContentTypeForm: FormSerializer{},
+	}
+}
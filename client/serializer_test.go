@@ -0,0 +1,149 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/tkliner/go-gopay/client/config"
+)
+
+func TestFormSerializerEncodeStruct(t *testing.T) {
+	type capture struct {
+		Amount int64  `json:"amount"`
+		Note   string `json:"note,omitempty"`
+		Hidden string `json:"-"`
+	}
+
+	data, err := FormSerializer{}.Encode(&capture{Amount: 1500, Hidden: "x"})
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	if string(data) != "amount=1500" {
+		t.Fatalf("unexpected form %q", data)
+	}
+}
+
+func TestFormSerializerDecode(t *testing.T) {
+	var got map[string]string
+	if err := (FormSerializer{}).Decode([]byte("id=42&result=ACCEPTED"), &got); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if got["id"] != "42" || got["result"] != "ACCEPTED" {
+		t.Fatalf("unexpected values %v", got)
+	}
+
+	var unsupported struct{}
+	if err := (FormSerializer{}).Decode([]byte("id=42"), &unsupported); err == nil {
+		t.Fatal("expected error for unsupported target")
+	}
+}
+
+func TestRequestContentNegotiation(t *testing.T) {
+	var got *http.Request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		w.Header().Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+		w.Write([]byte("id=42&result=ACCEPTED"))
+	}))
+	defer srv.Close()
+
+	var resp url.Values
+	err := newTestClient(t, srv.URL).Post().
+		Resource("/payments/payment/42/capture").
+		ContentType(ContentTypeForm).
+		Do(context.Background()).
+		Convert(&resp)
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+
+	if ct := got.Header.Get("Content-Type"); ct != ContentTypeForm {
+		t.Errorf("unexpected content type %q", ct)
+	}
+	if accept := got.Header.Get("Accept"); accept != ContentTypeJSON {
+		t.Errorf("unexpected accept %q", accept)
+	}
+	if resp.Get("result") != "ACCEPTED" {
+		t.Errorf("form response not decoded: %v", resp)
+	}
+}
+
+func TestResultConvertBinary(t *testing.T) {
+	pdf := []byte("%PDF-1.4\x00\x01")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Write(pdf)
+	}))
+	defer srv.Close()
+
+	var body []byte
+	err := newTestClient(t, srv.URL).Post().
+		Resource("/accounts/account-statement").
+		Accept(ContentTypeOctetStream, "*/*").
+		Do(context.Background()).
+		Convert(&body)
+	if err != nil {
+		t.Fatalf("Convert: %v", err)
+	}
+	if string(body) != string(pdf) {
+		t.Fatalf("unexpected body %q", body)
+	}
+}
+
+func TestNewClientUnsupportedContentType(t *testing.T) {
+	cfg := config.NewConfig(
+		config.WithGatewayURL("https://gw.sandbox.gopay.com"),
+		config.WithContentType("application/xml"),
+	)
+
+	if _, err := NewClient(cfg, http.DefaultClient); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+// textSerializer encodes strings as text/plain.
+type textSerializer struct{}
+
+func (textSerializer) ContentType() string { return "text/plain" }
+
+func (textSerializer) Encode(v any) ([]byte, error) { return []byte(fmt.Sprint(v)), nil }
+
+func (textSerializer) Decode(data []byte, v any) error {
+	*v.(*string) = string(data)
+	return nil
+}
+
+func TestNewClientCustomSerializer(t *testing.T) {
+	var gotType, gotBody string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		gotType, gotBody = r.Header.Get("Content-Type"), string(body)
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Write([]byte("pong"))
+	}))
+	defer srv.Close()
+
+	cfg := config.NewConfig(
+		config.WithGatewayURL(srv.URL),
+		config.WithContentType("text/plain"),
+		config.WithSerializer(textSerializer{}),
+		config.WithLogger(&mockLogger{}),
+	)
+	c, err := NewClient(cfg, http.DefaultClient)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+
+	var resp string
+	if err := c.Post().Resource("/ping").Encode("ping").Do(context.Background()).Convert(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if gotType != "text/plain" || gotBody != "ping" || resp != "pong" {
+		t.Errorf("unexpected exchange %q %q -> %q", gotType, gotBody, resp)
+	}
+}
@@ -0,0 +1,129 @@
+package file
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/tkliner/go-gopay/client/storage"
+)
+
+// FileIdempotencyStorage persists payment records as a JSON file so that they survive
+// restarts. Every change rewrites the file atomically. It is safe for concurrent use
+// within one process; several processes must not share the same file.
+type FileIdempotencyStorage struct {
+	mu      sync.Mutex
+	path    string
+	records map[string]storage.PaymentRecord
+}
+
+// NewFileIdempotencyStorage opens the storage at path, loading existing records.
+// The file is created on the first change.
+func NewFileIdempotencyStorage(path string) (*FileIdempotencyStorage, error) {
+	s := &FileIdempotencyStorage{
+		path:    path,
+		records: make(map[string]storage.PaymentRecord),
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read idempotency storage: %w", err)
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &s.records); err != nil {
+			return nil, fmt.Errorf("failed to decode idempotency storage %s: %w", path, err)
+		}
+	}
+
+	return s, nil
+}
+
+// Reserve stores the record unless the order number is already known.
+func (s *FileIdempotencyStorage) Reserve(record storage.PaymentRecord) (storage.PaymentRecord, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if existing, ok := s.records[record.OrderNumber]; ok {
+		return existing, false, nil
+	}
+
+	s.records[record.OrderNumber] = record
+	if err := s.flush(); err != nil {
+		delete(s.records, record.OrderNumber)
+		return storage.PaymentRecord{}, false, err
+	}
+	return record, true, nil
+}
+
+// Complete sets the payment ID of a reserved order number.
+func (s *FileIdempotencyStorage) Complete(orderNumber string, paymentId int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, ok := s.records[orderNumber]
+	if !ok {
+		return fmt.Errorf("order number %q is not reserved", orderNumber)
+	}
+	record.PaymentId = paymentId
+	s.records[orderNumber] = record
+	return s.flush()
+}
+
+// Release removes the record of the order number.
+func (s *FileIdempotencyStorage) Release(orderNumber string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.records[orderNumber]; !ok {
+		return nil
+	}
+	delete(s.records, orderNumber)
+	return s.flush()
+}
+
+// Get returns the record of the order number, if any.
+func (s *FileIdempotencyStorage) Get(orderNumber string) (storage.PaymentRecord, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, ok := s.records[orderNumber]
+	return record, ok, nil
+}
+
+// flush writes all records to a temporary file and renames it over the storage file.
+func (s *FileIdempotencyStorage) flush() error {
+	data, err := json.MarshalIndent(s.records, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode idempotency storage: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write idempotency storage: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write idempotency storage: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write idempotency storage: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write idempotency storage: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to write idempotency storage: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package inmemory
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/tkliner/go-gopay/client/storage"
+)
+
+// InMemoryIdempotencyStorage is an in-memory implementation of the IdempotencyStorage interface.
+// It is safe for concurrent use but records are lost when the process exits.
+type InMemoryIdempotencyStorage struct {
+	mu      sync.Mutex
+	records map[string]storage.PaymentRecord
+}
+
+// NewInMemoryIdempotencyStorage creates a new instance of InMemoryIdempotencyStorage.
+func NewInMemoryIdempotencyStorage() storage.IdempotencyStorage {
+	return &InMemoryIdempotencyStorage{
+		records: make(map[string]storage.PaymentRecord),
+	}
+}
+
+// Reserve stores the record unless the order number is already known.
+func (s *InMemoryIdempotencyStorage) Reserve(record storage.PaymentRecord) (storage.PaymentRecord, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if existing, ok := s.records[record.OrderNumber]; ok {
+		return existing, false, nil
+	}
+	s.records[record.OrderNumber] = record
+	return record, true, nil
+}
+
+// Complete sets the payment ID of a reserved order number.
+func (s *InMemoryIdempotencyStorage) Complete(orderNumber string, paymentId int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, ok := s.records[orderNumber]
+	if !ok {
+		return fmt.Errorf("order number %q is not reserved", orderNumber)
+	}
+	record.PaymentId = paymentId
+	s.records[orderNumber] = record
+	return nil
+}
+
+// Release removes the record of the order number.
+func (s *InMemoryIdempotencyStorage) Release(orderNumber string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.records, orderNumber)
+	return nil
+}
+
+// Get returns the record of the order number, if any.
+func (s *InMemoryIdempotencyStorage) Get(orderNumber string) (storage.PaymentRecord, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, ok := s.records[orderNumber]
+	return record, ok, nil
+}
@@ -8,4 +8,28 @@ type TokenStorage interface {
 	SaveAccessToken(token string, expiresAt time.Time) error
 	// GetAccessToken načte uložený přístupový token.
 	GetAccessToken() (string, time.Time, error)
-}
\ No newline at end of file
+}
+
+// PaymentRecord maps an order number to the payment created for it.
+type PaymentRecord struct {
+	OrderNumber string `json:"order_number"`
+	// PaymentId is zero while the creation is in flight or its outcome is unknown.
+	PaymentId int64 `json:"payment_id,omitempty"`
+	// Fingerprint identifies the request body used to create the payment.
+	Fingerprint string    `json:"fingerprint"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// IdempotencyStorage keeps track of payments created per order number so that a
+// retried creation does not create a second payment.
+type IdempotencyStorage interface {
+	// Reserve atomically stores the record unless the order number is already known.
+	// It returns the stored record and true on success, or the existing record and false.
+	Reserve(record PaymentRecord) (PaymentRecord, bool, error)
+	// Complete sets the payment ID of a reserved order number.
+	Complete(orderNumber string, paymentId int64) error
+	// Release removes the record so that the order number can be used again.
+	Release(orderNumber string) error
+	// Get returns the record of the order number, if any.
+	Get(orderNumber string) (PaymentRecord, bool, error)
+}
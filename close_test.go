@@ -0,0 +1,183 @@
+package gopay
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/tkliner/go-gopay/client/config"
+)
+
+// verifyNoLeaks fails the test if goroutines of the client packages are still
+// running shortly after the call, like goleak.VerifyNone.
+func verifyNoLeaks(t *testing.T) {
+	t.Helper()
+	verifyNoGoroutines(t, "github.com/tkliner/go-gopay/client")
+}
+
+// verifyNoGoroutines fails the test if goroutines whose stack contains match are
+// still running shortly after the call.
+func verifyNoGoroutines(t *testing.T, match string) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		leaked := goroutines(match)
+		if len(leaked) == 0 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("leaked goroutines:\n\n%s", strings.Join(leaked, "\n\n"))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func clientGoroutines() []string {
+	return goroutines("github.com/tkliner/go-gopay/client")
+}
+
+func goroutines(match string) []string {
+	buf := make([]byte, 1<<20)
+	buf = buf[:runtime.Stack(buf, true)]
+
+	var leaked []string
+	for _, g := range strings.Split(string(buf), "\n\n") {
+		if strings.Contains(g, match) {
+			leaked = append(leaked, g)
+		}
+	}
+	return leaked
+}
+
+func TestCloseStopsAutoRefresh(t *testing.T) {
+	srv := newTestGateway(t, func(w http.ResponseWriter, r *http.Request) {})
+	c := newTestClient(t, srv, config.WithAutoRefresh())
+
+	if len(clientGoroutines()) == 0 {
+		t.Fatal("auto-refresh goroutine not started")
+	}
+
+	if err := c.Close(context.Background()); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	verifyNoLeaks(t)
+}
+
+func TestCloseRejectsLaterCalls(t *testing.T) {
+	var calls atomic.Int32
+	srv := newTestGateway(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+	})
+	c := newTestClient(t, srv)
+
+	if err := c.Close(context.Background()); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	_, err := c.Payment().GetPayment(context.Background(), 1001)
+	if !errors.Is(err, ErrClosed) {
+		t.Fatalf("expected ErrClosed, got %v", err)
+	}
+	if calls.Load() != 0 {
+		t.Error("request was sent after Close")
+	}
+
+	if err := c.Close(context.Background()); err != nil {
+		t.Errorf("second Close: %v", err)
+	}
+}
+
+func TestCloseDrainsInFlight(t *testing.T) {
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	srv := newTestGateway(t, func(w http.ResponseWriter, r *http.Request) {
+		close(entered)
+		<-release
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(paymentStatusJSON))
+	})
+	c := newTestClient(t, srv)
+
+	called := make(chan error, 1)
+	go func() {
+		_, err := c.Payment().GetPayment(context.Background(), 3000006529)
+		called <- err
+	}()
+	<-entered
+
+	closed := make(chan error, 1)
+	go func() {
+		closed <- c.Close(context.Background())
+	}()
+
+	select {
+	case err := <-closed:
+		t.Fatalf("Close returned with a call in flight: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+
+	if err := <-called; err != nil {
+		t.Errorf("call in flight failed: %v", err)
+	}
+	if err := <-closed; err != nil {
+		t.Errorf("Close: %v", err)
+	}
+
+	verifyNoLeaks(t)
+}
+
+func TestCloseDeadline(t *testing.T) {
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	srv := newTestGateway(t, func(w http.ResponseWriter, r *http.Request) {
+		close(entered)
+		<-release
+	})
+	c := newTestClient(t, srv, config.WithAutoRefresh())
+
+	called := make(chan error, 1)
+	go func() {
+		_, err := c.Payment().GetPayment(context.Background(), 1001)
+		called <- err
+	}()
+	<-entered
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if err := c.Close(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+
+	close(release)
+	<-called
+
+	verifyNoLeaks(t)
+}
+
+func TestCloseNewWithClient(t *testing.T) {
+	srv := newTestGateway(t, func(w http.ResponseWriter, r *http.Request) {})
+
+	c, err := NewWithClient(config.NewConfig(config.WithGatewayURL(srv.URL)), srv.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Close(context.Background()); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	_, err = c.Payment().GetPayment(context.Background(), 1001)
+	if !errors.Is(err, ErrClosed) {
+		t.Fatalf("expected ErrClosed, got %v", err)
+	}
+}
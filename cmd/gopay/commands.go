@@ -0,0 +1,247 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	accountApi "github.com/tkliner/go-gopay/apis/account"
+	paymentApi "github.com/tkliner/go-gopay/apis/payment"
+)
+
+func paymentID(args []string) (int64, error) {
+	if len(args) != 1 {
+		return 0, &usageError{}
+	}
+	id, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid payment id %q", args[0])
+	}
+	return id, nil
+}
+
+func paymentGetCmd(_ *flag.FlagSet) func(e *env, args []string) error {
+	return func(e *env, args []string) error {
+		id, err := paymentID(args)
+		if err != nil {
+			return err
+		}
+
+		p, err := e.client.Payment().GetPayment(e.ctx, id)
+		if err != nil {
+			return err
+		}
+
+		return e.out.print(p, func(t *tabwriter.Writer) {
+			row(t, "ID", p.Id)
+			row(t, "ORDER NUMBER", p.OrderNumber)
+			row(t, "STATE", p.State)
+			if p.SubState != "" {
+				row(t, "SUB STATE", p.SubState)
+			}
+			row(t, "AMOUNT", formatAmount(p.Amount, p.Currency))
+			row(t, "INSTRUMENT", p.PaymentInstrument)
+			if p.Payer != nil && p.Payer.Contact != nil && p.Payer.Contact.Email != "" {
+				row(t, "PAYER", p.Payer.Contact.Email)
+			}
+			if p.Payer != nil && p.Payer.PaymentCard != nil {
+				row(t, "CARD", p.Payer.PaymentCard.CardBrand+" "+p.Payer.PaymentCard.CardNumber)
+			}
+			if p.Preauthorization != nil && p.Preauthorization.Requested {
+				row(t, "PREAUTHORIZATION", p.Preauthorization.State)
+			}
+			row(t, "GATEWAY URL", p.GatewayURL)
+		})
+	}
+}
+
+func paymentRefundCmd(fs *flag.FlagSet) func(e *env, args []string) error {
+	amount := fs.String("amount", "", "amount to refund in major units, e.g. 100.50")
+
+	return func(e *env, args []string) error {
+		id, err := paymentID(args)
+		if err != nil {
+			return err
+		}
+		if *amount == "" {
+			return &usageError{}
+		}
+
+		// The amount is parsed in the currency of the payment, so that "100" always
+		// means 100 crowns and never 100 haléřů.
+		p, err := e.client.Payment().GetPayment(e.ctx, id)
+		if err != nil {
+			return err
+		}
+		money, err := paymentApi.ParseMoney(*amount, p.Currency)
+		if err != nil {
+			return err
+		}
+		if money.Amount() <= 0 || money.Amount() > p.Amount {
+			return fmt.Errorf("refund amount %s must be positive and at most %s", money, formatAmount(p.Amount, p.Currency))
+		}
+
+		resp, err := e.client.Payment().RefundPayment(e.ctx, id, money.Amount())
+		if err != nil {
+			return err
+		}
+		return printOperation(e, resp)
+	}
+}
+
+func paymentCaptureCmd(_ *flag.FlagSet) func(e *env, args []string) error {
+	return func(e *env, args []string) error {
+		id, err := paymentID(args)
+		if err != nil {
+			return err
+		}
+		resp, err := e.client.Payment().CapturePayment(e.ctx, id)
+		if err != nil {
+			return err
+		}
+		return printOperation(e, resp)
+	}
+}
+
+func paymentVoidCmd(_ *flag.FlagSet) func(e *env, args []string) error {
+	return func(e *env, args []string) error {
+		id, err := paymentID(args)
+		if err != nil {
+			return err
+		}
+		resp, err := e.client.Payment().VoidAuthorization(e.ctx, id)
+		if err != nil {
+			return err
+		}
+		return printOperation(e, resp)
+	}
+}
+
+func printOperation(e *env, resp *paymentApi.OperationResponse) error {
+	return e.out.print(resp, func(t *tabwriter.Writer) {
+		row(t, "ID", "RESULT")
+		row(t, resp.Id, resp.Result)
+	})
+}
+
+func instrumentsListCmd(fs *flag.FlagSet) func(e *env, args []string) error {
+	currency := fs.String("currency", string(paymentApi.CZK), "currency of the instruments")
+
+	return func(e *env, args []string) error {
+		if len(args) != 0 {
+			return &usageError{}
+		}
+		c, err := paymentApi.ParseCurrency(*currency)
+		if err != nil {
+			return err
+		}
+
+		resp, err := e.client.Eshop().GetPaymentInstruments(e.ctx, c)
+		if err != nil {
+			return err
+		}
+
+		return e.out.print(resp, func(t *tabwriter.Writer) {
+			row(t, "INSTRUMENT", "GROUP", "LABEL", "SWIFTS")
+			for _, i := range resp.EnabledPaymentInstruments {
+				row(t, i.PaymentInstrument, i.Group, label(i.Label), len(i.EnabledSwifts))
+			}
+		})
+	}
+}
+
+func statementDownloadCmd(fs *flag.FlagSet) func(e *env, args []string) error {
+	from := fs.String("from", "", "first day of the statement, YYYY-MM-DD")
+	to := fs.String("to", "", "last day of the statement, YYYY-MM-DD")
+	currency := fs.String("currency", string(paymentApi.CZK), "account currency")
+	format := fs.String("format", string(accountApi.FormatCSVA), "statement format")
+	out := fs.String("out", "-", "output file, - for standard output")
+
+	return func(e *env, args []string) error {
+		if len(args) != 0 || *from == "" || *to == "" {
+			return &usageError{}
+		}
+		for _, d := range []string{*from, *to} {
+			if _, err := time.Parse(time.DateOnly, d); err != nil {
+				return fmt.Errorf("invalid date %q, expected YYYY-MM-DD", d)
+			}
+		}
+		c, err := paymentApi.ParseCurrency(*currency)
+		if err != nil {
+			return err
+		}
+
+		data, err := e.client.Account().GetAccountStatement(e.ctx, &accountApi.StatementRequest{
+			DateFrom: *from,
+			DateTo:   *to,
+			Currency: c,
+			Format:   accountApi.StatementFormat(*format),
+		})
+		if err != nil {
+			return err
+		}
+
+		if *out == "-" {
+			_, err = e.stdout.Write(data)
+			return err
+		}
+		return os.WriteFile(*out, data, 0o600)
+	}
+}
+
+func tokenStatusCmd(_ *flag.FlagSet) func(e *env, args []string) error {
+	return func(e *env, args []string) error {
+		if len(args) != 0 {
+			return &usageError{}
+		}
+
+		authenticator := e.client.Authenticator()
+		if _, err := authenticator.GetAccessToken(e.ctx); err != nil {
+			return err
+		}
+		token, err := authenticator.Status()
+		if err != nil {
+			return err
+		}
+
+		status := struct {
+			GoId        int64     `json:"goid"`
+			GatewayURL  string    `json:"gateway_url"`
+			Scope       string    `json:"scope"`
+			Fingerprint string    `json:"fingerprint"`
+			ExpiresAt   time.Time `json:"expires_at"`
+		}{e.profile.GoId, e.profile.GatewayURL, string(token.Scope), token.Fingerprint, token.ExpiresAt}
+
+		return e.out.print(status, func(t *tabwriter.Writer) {
+			row(t, "GOID", status.GoId)
+			row(t, "GATEWAY", status.GatewayURL)
+			row(t, "SCOPE", status.Scope)
+			row(t, "FINGERPRINT", status.Fingerprint)
+			row(t, "EXPIRES AT", status.ExpiresAt.Format(time.RFC3339))
+			row(t, "EXPIRES IN", time.Until(status.ExpiresAt).Round(time.Second))
+		})
+	}
+}
+
+func formatAmount(amount paymentApi.Amount, currency paymentApi.Currency) string {
+	m, err := paymentApi.NewMoney(amount, currency)
+	if err != nil {
+		return fmt.Sprintf("%d %s", amount, currency)
+	}
+	return m.String()
+}
+
+func label(labels map[string]string) string {
+	for _, lang := range []string{"en", "cs"} {
+		if l, ok := labels[lang]; ok {
+			return l
+		}
+	}
+	for _, l := range labels {
+		return l
+	}
+	return ""
+}
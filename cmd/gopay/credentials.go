@@ -0,0 +1,122 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/tkliner/go-gopay/client/config"
+)
+
+const (
+	envGoId         = "GOPAY_GOID"
+	envClientId     = "GOPAY_CLIENT_ID"
+	envClientSecret = "GOPAY_CLIENT_SECRET"
+	envGatewayURL   = "GOPAY_GATEWAY_URL"
+	envProfiles     = "GOPAY_PROFILES"
+	envProfile      = "GOPAY_PROFILE"
+)
+
+// profile holds the credentials of one GoPay e-shop in the profiles file.
+type profile struct {
+	GoId         int64  `json:"goid"`
+	ClientId     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+	GatewayURL   string `json:"gateway_url,omitempty"`
+	Sandbox      bool   `json:"sandbox,omitempty"`
+}
+
+// defaultProfilesPath returns ~/.config/gopay/profiles.json, honouring XDG_CONFIG_HOME.
+func defaultProfilesPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "gopay", "profiles.json")
+}
+
+// loadCredentials resolves credentials from the profiles file and the environment.
+// Environment variables override values from the profile, --sandbox overrides both.
+func loadCredentials(profilesPath, name string, sandbox bool) (profile, error) {
+	var p profile
+
+	if profilesPath == "" {
+		profilesPath = os.Getenv(envProfiles)
+	}
+	if profilesPath == "" {
+		profilesPath = defaultProfilesPath()
+	}
+	if name == "" {
+		name = os.Getenv(envProfile)
+	}
+	explicit := name != ""
+	if name == "" {
+		name = "default"
+	}
+
+	if profilesPath != "" {
+		profiles, err := readProfiles(profilesPath)
+		if err != nil {
+			return p, err
+		}
+		found, ok := profiles[name]
+		if !ok && explicit {
+			return p, fmt.Errorf("profile %q not found in %s", name, profilesPath)
+		}
+		p = found
+	}
+
+	if v := os.Getenv(envGoId); v != "" {
+		goId, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return p, fmt.Errorf("invalid %s: %w", envGoId, err)
+		}
+		p.GoId = goId
+	}
+	if v := os.Getenv(envClientId); v != "" {
+		p.ClientId = v
+	}
+	if v := os.Getenv(envClientSecret); v != "" {
+		p.ClientSecret = v
+	}
+	if v := os.Getenv(envGatewayURL); v != "" {
+		p.GatewayURL = v
+	}
+
+	if sandbox {
+		p.Sandbox = true
+		p.GatewayURL = ""
+	}
+	if p.GatewayURL == "" {
+		p.GatewayURL = config.ProductionGatewayURL
+		if p.Sandbox {
+			p.GatewayURL = config.SandboxGatewayURL
+		}
+	}
+
+	if p.GoId == 0 || p.ClientId == "" || p.ClientSecret == "" {
+		return p, fmt.Errorf("missing credentials: set %s, %s and %s or use a profile", envGoId, envClientId, envClientSecret)
+	}
+
+	return p, nil
+}
+
+func readProfiles(path string) (map[string]profile, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return map[string]profile{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read profiles: %w", err)
+	}
+
+	profiles := map[string]profile{}
+	if err := json.Unmarshal(data, &profiles); err != nil {
+		return nil, fmt.Errorf("failed to parse profiles %s: %w", path, err)
+	}
+	return profiles, nil
+}
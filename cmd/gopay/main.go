@@ -0,0 +1,214 @@
+// Command gopay is an operations tool for inspecting and managing GoPay payments.
+//
+// Usage:
+//
+//	gopay [flags] payment get <id>
+//	gopay [flags] payment refund <id> -amount 100.50
+//	gopay [flags] payment capture <id>
+//	gopay [flags] payment void <id>
+//	gopay [flags] instruments list [-currency CZK]
+//	gopay [flags] statement download -from 2024-01-01 -to 2024-01-31 [-currency CZK] [-format CSV_A] [-out file]
+//	gopay [flags] token status
+//
+// Credentials are read from the profiles file (~/.config/gopay/profiles.json or
+// $GOPAY_PROFILES) and can be overridden by GOPAY_GOID, GOPAY_CLIENT_ID,
+// GOPAY_CLIENT_SECRET and GOPAY_GATEWAY_URL. The profiles file maps profile names
+// to objects with goid, client_id, client_secret and optional sandbox or gateway_url.
+//
+// Flags, accepted anywhere on the command line:
+//
+//	-profile name    profile to use (default "default" or $GOPAY_PROFILE)
+//	-profiles path   profiles file
+//	-sandbox         use the GoPay sandbox gateway
+//	-output format   table or json (default table)
+//	-timeout d       timeout of the whole command (default 30s)
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/tkliner/go-gopay"
+	"github.com/tkliner/go-gopay/client/config"
+)
+
+type globals struct {
+	profile  string
+	profiles string
+	sandbox  bool
+	output   string
+	timeout  time.Duration
+}
+
+// env carries everything a command handler needs.
+type env struct {
+	ctx     context.Context
+	client  gopay.Clienter
+	profile profile
+	out     *printer
+	stdout  io.Writer
+}
+
+type command struct {
+	usage string
+	flags func(fs *flag.FlagSet) func(e *env, args []string) error
+}
+
+var commands = map[string]map[string]command{
+	"payment": {
+		"get":     {usage: "payment get <id>", flags: paymentGetCmd},
+		"refund":  {usage: "payment refund <id> -amount <decimal>", flags: paymentRefundCmd},
+		"capture": {usage: "payment capture <id>", flags: paymentCaptureCmd},
+		"void":    {usage: "payment void <id>", flags: paymentVoidCmd},
+	},
+	"instruments": {
+		"list": {usage: "instruments list [-currency CZK]", flags: instrumentsListCmd},
+	},
+	"statement": {
+		"download": {usage: "statement download -from <date> -to <date> [-currency CZK] [-format CSV_A] [-out file]", flags: statementDownloadCmd},
+	},
+	"token": {
+		"status": {usage: "token status", flags: tokenStatusCmd},
+	},
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	g := &globals{}
+
+	group, sub := findCommand(args)
+	cmd, ok := commands[group][sub]
+	if !ok {
+		usage(stderr)
+		return 2
+	}
+
+	fs := newFlagSet(group+" "+sub, g, stderr)
+	handler := cmd.flags(fs)
+	rest, err := parseInterspersed(fs, args)
+	if err != nil {
+		return 2
+	}
+	rest = rest[2:]
+
+	if g.output != outputTable && g.output != outputJSON {
+		fmt.Fprintf(stderr, "unknown output format %q\n", g.output)
+		return 2
+	}
+
+	p, err := loadCredentials(g.profiles, g.profile, g.sandbox)
+	if err != nil {
+		fmt.Fprintln(stderr, "gopay:", err)
+		return 1
+	}
+
+	client, err := gopay.New(config.NewConfig(
+		config.WithCredentials(p.GoId, p.ClientId, p.ClientSecret),
+		config.WithGatewayURL(p.GatewayURL),
+		config.WithTimeout(g.timeout),
+	))
+	if err != nil {
+		fmt.Fprintln(stderr, "gopay:", err)
+		return 1
+	}
+
+	defer client.Close(context.Background())
+
+	ctx, cancel := context.WithTimeout(context.Background(), g.timeout)
+	defer cancel()
+
+	e := &env{
+		ctx:     ctx,
+		client:  client,
+		profile: p,
+		out:     &printer{w: stdout, format: g.output},
+		stdout:  stdout,
+	}
+
+	if err := handler(e, rest); err != nil {
+		var usageErr *usageError
+		if errors.As(err, &usageErr) {
+			fmt.Fprintf(stderr, "usage: gopay %s\n", cmd.usage)
+			return 2
+		}
+		fmt.Fprintln(stderr, "gopay:", err)
+		return 1
+	}
+
+	return 0
+}
+
+func newFlagSet(name string, g *globals, stderr io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.StringVar(&g.profile, "profile", g.profile, "credentials profile")
+	fs.StringVar(&g.profiles, "profiles", g.profiles, "profiles file")
+	fs.BoolVar(&g.sandbox, "sandbox", g.sandbox, "use the GoPay sandbox")
+	fs.StringVar(&g.output, "output", outputTable, "output format: table or json")
+	fs.DurationVar(&g.timeout, "timeout", config.DefaultTimeout, "command timeout")
+	fs.Usage = func() { usage(stderr) }
+	return fs
+}
+
+// findCommand returns the first two positional arguments, skipping global flags and their values.
+func findCommand(args []string) (string, string) {
+	var positional []string
+	for i := 0; i < len(args) && len(positional) < 2; i++ {
+		arg := args[i]
+		if len(arg) > 1 && arg[0] == '-' {
+			name := strings.TrimLeft(arg, "-")
+			if !strings.Contains(name, "=") && globalValueFlags[name] {
+				i++
+			}
+			continue
+		}
+		positional = append(positional, arg)
+	}
+	if len(positional) < 2 {
+		return "", ""
+	}
+	return positional[0], positional[1]
+}
+
+var globalValueFlags = map[string]bool{"profile": true, "profiles": true, "output": true, "timeout": true}
+
+// parseInterspersed parses flags appearing anywhere among args and returns the positional arguments.
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "usage: gopay [-profile name] [-sandbox] [-output table|json] <command>")
+	fmt.Fprintln(w, "commands:")
+	for _, group := range []string{"payment", "instruments", "statement", "token"} {
+		for _, sub := range []string{"get", "refund", "capture", "void", "list", "download", "status"} {
+			if cmd, ok := commands[group][sub]; ok {
+				fmt.Fprintln(w, "  "+cmd.usage)
+			}
+		}
+	}
+}
+
+type usageError struct{}
+
+func (*usageError) Error() string { return "invalid usage" }
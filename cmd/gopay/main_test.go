@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tkliner/go-gopay/client/auth"
+	"github.com/tkliner/go-gopay/client/config"
+)
+
+func newTestGateway(t *testing.T) (*httptest.Server, *string) {
+	var refundBody string
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/api/oauth2/token":
+			w.Write([]byte(`{"access_token":"abcdefghijklmnop","expires_in":1800}`))
+		case r.URL.Path == "/api/payments/payment/42":
+			w.Write([]byte(`{"id":42,"order_number":"A-1","state":"PAID","amount":25000,"currency":"CZK"}`))
+		case r.URL.Path == "/api/payments/payment/42/refund":
+			r.ParseForm()
+			refundBody = r.PostForm.Encode()
+			w.Write([]byte(`{"id":42,"result":"FINISHED"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	t.Setenv(envGatewayURL, srv.URL)
+	t.Setenv(envProfiles, filepath.Join(t.TempDir(), "none.json"))
+	t.Setenv(envGoId, "8123456789")
+	t.Setenv(envClientId, "client")
+	t.Setenv(envClientSecret, "secret")
+
+	return srv, &refundBody
+}
+
+func TestRunPaymentGet(t *testing.T) {
+	newTestGateway(t)
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"payment", "get", "42", "-output", "json"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr.String())
+	}
+
+	var resp map[string]any
+	if err := json.Unmarshal(stdout.Bytes(), &resp); err != nil || resp["state"] != "PAID" {
+		t.Errorf("unexpected output %s", stdout.String())
+	}
+}
+
+func TestRunPaymentRefundUsesPaymentCurrency(t *testing.T) {
+	_, refundBody := newTestGateway(t)
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"payment", "refund", "42", "-amount", "100.50"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr.String())
+	}
+	if *refundBody != "amount=10050" {
+		t.Errorf("unexpected refund body %q", *refundBody)
+	}
+
+	if code := run([]string{"payment", "refund", "42", "-amount", "1000"}, &stdout, &stderr); code != 1 {
+		t.Errorf("expected refund over the paid amount to fail, got %d", code)
+	}
+}
+
+func TestRunTokenStatusHidesToken(t *testing.T) {
+	newTestGateway(t)
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"-output", "table", "token", "status"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr.String())
+	}
+	if strings.Contains(stdout.String(), "abcd") || !strings.Contains(stdout.String(), auth.Fingerprint("abcdefghijklmnop")) {
+		t.Errorf("token not hidden: %s", stdout.String())
+	}
+}
+
+func TestLoadCredentialsFromProfile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "profiles.json")
+	os.WriteFile(path, []byte(`{"shop": {"goid": 1, "client_id": "id", "client_secret": "s", "sandbox": true}}`), 0o600)
+
+	for _, name := range []string{envGoId, envClientId, envClientSecret, envGatewayURL, envProfile} {
+		t.Setenv(name, "")
+	}
+
+	p, err := loadCredentials(path, "shop", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.GatewayURL != config.SandboxGatewayURL || p.GoId != 1 {
+		t.Errorf("unexpected profile %+v", p)
+	}
+
+	if _, err := loadCredentials(path, "missing", false); err == nil {
+		t.Error("expected error for unknown profile")
+	}
+}
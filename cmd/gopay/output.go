@@ -0,0 +1,42 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+)
+
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+)
+
+// printer writes command results either as JSON or as an aligned table.
+type printer struct {
+	w      io.Writer
+	format string
+}
+
+// print writes v as JSON, or as the rows produced by table.
+func (p *printer) print(v any, table func(t *tabwriter.Writer)) error {
+	if p.format == outputJSON {
+		enc := json.NewEncoder(p.w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	}
+
+	t := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+	table(t)
+	return t.Flush()
+}
+
+func row(t *tabwriter.Writer, cells ...any) {
+	for i, c := range cells {
+		if i > 0 {
+			fmt.Fprint(t, "\t")
+		}
+		fmt.Fprint(t, c)
+	}
+	fmt.Fprintln(t)
+}
@@ -0,0 +1,43 @@
+package gopay
+
+import (
+	"context"
+
+	eshopApi "github.com/tkliner/go-gopay/apis/eshop"
+	paymentApi "github.com/tkliner/go-gopay/apis/payment"
+	"github.com/tkliner/go-gopay/client"
+	"github.com/tkliner/go-gopay/client/config"
+)
+
+type EshopGetter interface {
+	Eshop() EshopInterface
+}
+
+type EshopInterface interface {
+	// GetPaymentInstruments lists the payment instruments enabled for the e-shop in the currency.
+	// It needs only a payment-create token, so that a storefront can offer them.
+	GetPaymentInstruments(ctx context.Context, currency paymentApi.Currency, opts ...CallOption) (*eshopApi.PaymentInstrumentsResponse, error)
+}
+
+type eshop struct {
+	client client.Interface
+	goId   int64
+}
+
+func newEshop(c client.Interface, goId int64) EshopInterface {
+	return &eshop{
+		client: c,
+		goId:   goId,
+	}
+}
+
+func (e *eshop) GetPaymentInstruments(ctx context.Context, currency paymentApi.Currency, opts ...CallOption) (*eshopApi.PaymentInstrumentsResponse, error) {
+	req := e.client.Get().
+		Resource(pathEshop+"/{goid}/payment-instruments/{currency}").
+		PathParam("goid", e.goId).
+		PathParam("currency", currency).
+		Scope(config.TokenScopeCreatePayment)
+
+	resp, _, err := client.Do[eshopApi.PaymentInstrumentsResponse](ctx, applyOptions(req, opts))
+	return resp, err
+}
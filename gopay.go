@@ -1,59 +1,172 @@
 package gopay
 
 import (
+	"context"
+	"io"
 	"net/http"
+	"time"
 
+	paymentApi "github.com/tkliner/go-gopay/apis/payment"
 	"github.com/tkliner/go-gopay/client"
+	"github.com/tkliner/go-gopay/client/auth"
 	"github.com/tkliner/go-gopay/client/config"
 	gopayHttp "github.com/tkliner/go-gopay/client/http"
 	"github.com/tkliner/go-gopay/client/logger"
+	"github.com/tkliner/go-gopay/client/storage"
 )
 
 const (
-	pathPayment = "/payments/payment"
+	pathPayment          = "/payments/payment"
+	pathEshop            = "/eshops/eshop"
+	pathAccountStatement = "/accounts/account-statement"
 )
 
+// ErrClosed is returned, wrapped, by calls made after Close.
+var ErrClosed = gopayHttp.ErrClosed
+
 type Clienter interface {
 	Client() client.Interface
 	PaymentGetter
+	EshopGetter
+	AccountGetter
+	// Authenticator returns the authenticator of the client.
+	Authenticator() auth.Authenticator
+	// CircuitState returns the state of the circuit breaker around the gateway.
+	CircuitState() gopayHttp.CircuitState
+	// RateLimitStats returns the load of the rate limited endpoint classes, nil without rate limits.
+	RateLimitStats() map[gopayHttp.EndpointClass]gopayHttp.RateLimitStats
+	// Raw sends an authenticated request to an endpoint the library does not wrap.
+	Raw(ctx context.Context, method, path string, body io.Reader, header http.Header) (*http.Response, error)
+	// Warmup fetches the access token and opens the connections to the gateway.
+	Warmup(ctx context.Context) error
+	// Ping verifies that the credentials work and the gateway is reachable, see GoPay.Ping.
+	Ping(ctx context.Context) (*Health, error)
+	// Close makes later calls fail with ErrClosed, waits for the calls in flight
+	// until ctx is done and stops the token auto-refresh.
+	Close(ctx context.Context) error
 }
 
 type GoPay struct {
-	client client.Interface
-	logger logger.Logger
+	client        client.Interface
+	logger        logger.Logger
+	goId          int64
+	idempotency   storage.IdempotencyStorage
+	pendingExpiry time.Duration
+	authenticator auth.Authenticator
+	breaker       *gopayHttp.CircuitBreaker
+	limiter       *gopayHttp.RateLimiter
+	lifecycle     *gopayHttp.Lifecycle
+	stack         *gopayHttp.Stack
+	environment   string
+	pingCurrency  paymentApi.Currency
 }
 
+// New creates a client with a transport built from the configuration, see
+// gopayHttp.NewStackWithClient for its layers.
 func New(config *config.Config) (Clienter, error) {
 	copy := *config
 	defaults(&copy)
-	httpClient, err := gopayHttp.NewHTTPClient(&copy)
+
+	g, err := newWithStack(&copy, &http.Client{Timeout: copy.Timeout})
 	if err != nil {
 		return nil, err
 	}
 
-	return NewWithClient(&copy, httpClient)
-
+	return g, nil
 }
 
+// NewWithClient creates a client on top of c, e.g. an instrumented one. Its transport
+// is wrapped with the same layers as in New, including authentication; its timeout,
+// cookie jar and redirect policy are kept.
 func NewWithClient(config *config.Config, c *http.Client) (Clienter, error) {
 	copy := *config
 	defaults(&copy)
 
-	cl, err := client.NewClient(&copy, c)
+	g, err := newWithStack(&copy, c)
+	if err != nil {
+		return nil, err
+	}
+
+	return g, nil
+}
+
+func newWithStack(config *config.Config, c *http.Client) (*GoPay, error) {
+	stack, err := gopayHttp.NewStackWithClient(config, c)
+	if err != nil {
+		return nil, err
+	}
+
+	g, err := newGoPay(config, stack.Client)
+	if err != nil {
+		stack.Close()
+		return nil, err
+	}
+	g.authenticator = stack.Authenticator
+	g.breaker = stack.Breaker
+	g.limiter = stack.Limiter
+	g.lifecycle = stack.Lifecycle
+	g.stack = stack
+
+	if config.EagerStartup {
+		ctx, cancel := context.WithTimeout(context.Background(), config.Timeout)
+		defer cancel()
+
+		if err := g.checkStartup(ctx, config); err != nil {
+			g.Close(context.Background())
+			return nil, err
+		}
+	}
+
+	return g, nil
+}
+
+func newGoPay(config *config.Config, c *http.Client) (*GoPay, error) {
+	cl, err := client.NewClient(config, c)
 
 	if err != nil {
 		return nil, err
 	}
 
+	environment := EnvironmentSandbox
+	if config.IsProduction {
+		environment = EnvironmentProduction
+	}
+
+	pingCurrency := paymentApi.CZK
+	if config.PingCurrency != "" {
+		pingCurrency = paymentApi.Currency(config.PingCurrency)
+	}
+
 	return &GoPay{
-		client: cl,
-		logger: copy.Logger,
+		client:        cl,
+		logger:        config.Logger,
+		goId:          config.GoId,
+		idempotency:   config.IdempotencyStorage,
+		pendingExpiry: config.PendingExpiry,
+		environment:   environment,
+		pingCurrency:  pingCurrency,
 	}, nil
 
 }
 
 func (g *GoPay) Payment() PaymentInterface {
-	return newPayment(g.client)
+	p := newPayment(g.client)
+	if g.idempotency != nil {
+		return newIdempotentPayment(p, g.idempotency, g.pendingExpiry, g.logger)
+	}
+	return p
+}
+
+func (g *GoPay) Eshop() EshopInterface {
+	return newEshop(g.client, g.goId)
+}
+
+func (g *GoPay) Account() AccountInterface {
+	return newAccount(g.client, g.goId)
+}
+
+func (g *GoPay) Authenticator() auth.Authenticator {
+	return g.authenticator
 }
 
 func defaults(cfg *config.Config) {
@@ -73,4 +186,33 @@ func defaults(cfg *config.Config) {
 
 func (g *GoPay) Client() client.Interface {
 	return g.client
-}
\ No newline at end of file
+}
+
+func (g *GoPay) CircuitState() gopayHttp.CircuitState {
+	if g.breaker == nil {
+		return gopayHttp.CircuitDisabled
+	}
+	return g.breaker.State()
+}
+
+// RateLimitStats returns the current load of the rate limited endpoint classes,
+// e.g. to export the queue depth as a metric. It is nil without config.RateLimits.
+func (g *GoPay) RateLimitStats() map[gopayHttp.EndpointClass]gopayHttp.RateLimitStats {
+	if g.limiter == nil {
+		return nil
+	}
+	return g.limiter.Stats()
+}
+
+// Close makes later calls fail with ErrClosed and waits until the calls in flight
+// finish or ctx is done, in which case the context error is returned. The token
+// auto-refresh is stopped in either case.
+func (g *GoPay) Close(ctx context.Context) error {
+	err := g.lifecycle.Close(ctx)
+
+	if g.stack != nil {
+		g.stack.Close()
+	}
+
+	return err
+}
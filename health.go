@@ -0,0 +1,136 @@
+package gopay
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/tkliner/go-gopay/client"
+	"github.com/tkliner/go-gopay/client/config"
+	gopayHttp "github.com/tkliner/go-gopay/client/http"
+)
+
+// Environments reported by Ping.
+const (
+	EnvironmentSandbox    = "sandbox"
+	EnvironmentProduction = "production"
+)
+
+const defaultHealthTimeout = 5 * time.Second
+
+// Health is the result of Ping. It never contains credentials or tokens.
+type Health struct {
+	Ready       bool   `json:"ready"`
+	Environment string `json:"environment"`
+	// TokenScope and TokenExpiresAt describe the cached access token, if any.
+	TokenScope     config.TokenScope `json:"token_scope,omitempty"`
+	TokenExpiresAt *time.Time        `json:"token_expires_at,omitempty"`
+	// LastSuccess is the time of the last call answered with a 2xx status.
+	LastSuccess  *time.Time             `json:"last_success,omitempty"`
+	CircuitState gopayHttp.CircuitState `json:"circuit_state"`
+	// Error describes why the client is not ready, without the details of the error.
+	Error string `json:"error,omitempty"`
+}
+
+// Ping obtains an access token and lists the payment instruments of the e-shop to
+// verify that the credentials work and the gateway is reachable. The instruments are
+// listed in CZK, or the currency set by config.WithPingCurrency, with a payment-create
+// token, which GoPay grants to credentials of either scope. The returned Health is
+// filled in also when Ping fails.
+func (g *GoPay) Ping(ctx context.Context) (*Health, error) {
+	err := g.ping(ctx)
+
+	h := &Health{
+		Ready:        err == nil,
+		Environment:  g.environment,
+		CircuitState: g.CircuitState(),
+	}
+	if g.authenticator != nil {
+		if status, statusErr := g.authenticator.Status(); statusErr == nil && status.Valid {
+			h.TokenScope = status.Scope
+			h.TokenExpiresAt = &status.ExpiresAt
+		}
+	}
+	if last := g.lifecycle.LastSuccess(); !last.IsZero() {
+		h.LastSuccess = &last
+	}
+	if err != nil {
+		h.Error = healthError(err)
+	}
+
+	return h, err
+}
+
+func (g *GoPay) ping(ctx context.Context) error {
+	if g.authenticator != nil {
+		if _, err := g.authenticator.GetAccessToken(ctx); err != nil {
+			return &gopayHttp.AuthError{Err: err}
+		}
+	}
+
+	return g.client.Get().
+		Resource(pathEshop+"/{goid}/payment-instruments/{currency}").
+		PathParam("goid", g.goId).
+		PathParam("currency", g.pingCurrency).
+		Scope(config.TokenScopeCreatePayment).
+		Do(ctx).
+		Error()
+}
+
+// healthError returns a description of the error that is safe to expose.
+func healthError(err error) string {
+	var authErr *gopayHttp.AuthError
+	var statusErr *client.StatusError
+
+	switch {
+	case errors.Is(err, ErrClosed):
+		return "client is closed"
+	case errors.Is(err, gopayHttp.ErrCircuitOpen):
+		return "circuit breaker is open"
+	case errors.As(err, &authErr):
+		return "failed to obtain access token"
+	case errors.As(err, &statusErr):
+		return fmt.Sprintf("gateway responded with HTTP %d", statusErr.StatusCode)
+	case errors.Is(err, context.DeadlineExceeded):
+		return "gateway did not respond in time"
+	default:
+		return "gateway is unreachable"
+	}
+}
+
+// NewHealthHandler returns a handler reporting the Health of the client as JSON,
+// with status 200 when it is ready and 503 otherwise. Each request pings the
+// gateway with the timeout, 5s if zero.
+func NewHealthHandler(c Clienter, timeout time.Duration) http.Handler {
+	if timeout <= 0 {
+		timeout = defaultHealthTimeout
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		health, err := c.Ping(ctx)
+
+		status := http.StatusOK
+		if err != nil {
+			status = http.StatusServiceUnavailable
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(status)
+		if r.Method == http.MethodGet {
+			json.NewEncoder(w).Encode(health)
+		}
+	})
+}
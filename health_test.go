@@ -0,0 +1,126 @@
+package gopay
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/tkliner/go-gopay/client/config"
+)
+
+func TestHealthHandlerReady(t *testing.T) {
+	srv := newTestGateway(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/eshops/eshop/8123456789/payment-instruments/CZK" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"groups": {}, "enabledPaymentInstruments": []}`))
+	})
+	c := newTestClient(t, srv)
+
+	rec := httptest.NewRecorder()
+	NewHealthHandler(c, 0).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("unexpected status %d: %s", rec.Code, rec.Body)
+	}
+	if strings.Contains(rec.Body.String(), "test-token") || strings.Contains(rec.Body.String(), "secret") {
+		t.Fatalf("health leaks secrets: %s", rec.Body)
+	}
+
+	var h Health
+	if err := json.Unmarshal(rec.Body.Bytes(), &h); err != nil {
+		t.Fatal(err)
+	}
+	if !h.Ready || h.Environment != EnvironmentSandbox || h.TokenExpiresAt == nil || h.LastSuccess == nil {
+		t.Errorf("unexpected health %+v", h)
+	}
+	if h.CircuitState != "disabled" {
+		t.Errorf("unexpected circuit state %s", h.CircuitState)
+	}
+}
+
+func TestHealthHandlerNotReady(t *testing.T) {
+	tests := map[string]struct {
+		token, api int
+		want       string
+	}{
+		"bad credentials": {token: http.StatusUnauthorized, api: http.StatusOK, want: "failed to obtain access token"},
+		"gateway error":   {token: http.StatusOK, api: http.StatusInternalServerError, want: "gateway responded with HTTP 500"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/api/oauth2/token" {
+					w.WriteHeader(tt.token)
+					w.Write([]byte(`{"access_token":"test-token","expires_in":1800,"client_secret":"secret"}`))
+					return
+				}
+				w.WriteHeader(tt.api)
+			}))
+			defer srv.Close()
+			c := newTestClient(t, srv)
+
+			rec := httptest.NewRecorder()
+			NewHealthHandler(c, 0).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+			if rec.Code != http.StatusServiceUnavailable {
+				t.Fatalf("unexpected status %d", rec.Code)
+			}
+			if strings.Contains(rec.Body.String(), "secret") {
+				t.Fatalf("health leaks secrets: %s", rec.Body)
+			}
+
+			var h Health
+			json.Unmarshal(rec.Body.Bytes(), &h)
+			if h.Ready || h.Error != tt.want {
+				t.Errorf("unexpected health %+v", h)
+			}
+		})
+	}
+}
+
+func TestPingClosed(t *testing.T) {
+	srv := newTestGateway(t, func(w http.ResponseWriter, r *http.Request) {})
+	c := newTestClient(t, srv, config.WithProduction())
+	c.Close(context.Background())
+
+	h, err := c.Ping(context.Background())
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if h.Error != "client is closed" || h.Environment != EnvironmentProduction {
+		t.Errorf("unexpected health %+v", h)
+	}
+}
+
+func TestHealthHandlerMethod(t *testing.T) {
+	srv := newTestGateway(t, func(w http.ResponseWriter, r *http.Request) {})
+
+	rec := httptest.NewRecorder()
+	NewHealthHandler(newTestClient(t, srv), 0).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/healthz", nil))
+
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("unexpected status %d", rec.Code)
+	}
+}
+
+func TestPingCurrency(t *testing.T) {
+	var path string
+	srv := newTestGateway(t, func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"groups": {}, "enabledPaymentInstruments": []}`))
+	})
+
+	if _, err := newTestClient(t, srv, config.WithPingCurrency("EUR")).Ping(context.Background()); err != nil {
+		t.Fatalf("Ping: %v", err)
+	}
+	if path != "/api/eshops/eshop/8123456789/payment-instruments/EUR" {
+		t.Errorf("unexpected probe %s", path)
+	}
+}
@@ -0,0 +1,227 @@
+package gopay
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	paymentApi "github.com/tkliner/go-gopay/apis/payment"
+	"github.com/tkliner/go-gopay/client"
+	gopayHttp "github.com/tkliner/go-gopay/client/http"
+	"github.com/tkliner/go-gopay/client/logger"
+	"github.com/tkliner/go-gopay/client/storage"
+)
+
+// IdempotencyConflictError is returned when an order number is reused for a payment
+// that differs from the one originally created for it.
+type IdempotencyConflictError struct {
+	OrderNumber string
+	PaymentId   int64
+}
+
+func (e *IdempotencyConflictError) Error() string {
+	return fmt.Sprintf("order number %q was already used for a different payment", e.OrderNumber)
+}
+
+// ErrNoIdempotencyStorage is returned by ResolvePendingPayment and ReleasePendingPayment
+// when the client was created without config.WithIdempotencyStorage.
+var ErrNoIdempotencyStorage = errors.New("idempotency storage is not configured")
+
+// PaymentPendingError is returned when a previous creation for the order number is still
+// in flight or ended without a response (e.g. a timeout). Whether GoPay created the payment
+// is unknown, so CreatePayment refuses to send it again until the order number is resolved:
+//
+//   - if GoPay notified about a payment with this order number, or the payment ID was
+//     found in the GoPay admin, call PaymentInterface.ResolvePendingPayment with the payment ID;
+//     retries of CreatePayment then return that payment,
+//   - if no payment exists for the order number, call PaymentInterface.ReleasePendingPayment;
+//     the next CreatePayment creates the payment,
+//   - or let config.WithPendingExpiry release stale reservations automatically.
+type PaymentPendingError struct {
+	OrderNumber string
+	Since       time.Time
+}
+
+func (e *PaymentPendingError) Error() string {
+	return fmt.Sprintf("creation of payment for order number %q started at %s has unknown outcome",
+		e.OrderNumber, e.Since.Format(time.RFC3339))
+}
+
+// idempotentPayment makes CreatePayment safe to retry. The first call for an order number
+// reserves it in the storage; retries with the same payment return the already created
+// payment instead of creating a new one.
+type idempotentPayment struct {
+	PaymentInterface
+
+	storage       storage.IdempotencyStorage
+	pendingExpiry time.Duration
+	logger        logger.Logger
+}
+
+func newIdempotentPayment(p PaymentInterface, s storage.IdempotencyStorage, pendingExpiry time.Duration, l logger.Logger) PaymentInterface {
+	return &idempotentPayment{
+		PaymentInterface: p,
+		storage:          s,
+		pendingExpiry:    pendingExpiry,
+		logger:           l,
+	}
+}
+
+func (p *idempotentPayment) CreatePayment(ctx context.Context, payment *paymentApi.Payment, opts ...CallOption) (*paymentApi.PaymentResponse, error) {
+	if err := payment.Validate(); err != nil {
+		return nil, err
+	}
+
+	fingerprint, err := paymentFingerprint(payment)
+	if err != nil {
+		return nil, err
+	}
+
+	record, reserved, err := p.reserve(ctx, storage.PaymentRecord{
+		OrderNumber: payment.OrderNumber,
+		Fingerprint: fingerprint,
+		CreatedAt:   time.Now(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to reserve order number %q: %w", payment.OrderNumber, err)
+	}
+
+	if !reserved {
+		if record.Fingerprint != fingerprint {
+			return nil, &IdempotencyConflictError{OrderNumber: record.OrderNumber, PaymentId: record.PaymentId}
+		}
+		if record.PaymentId == 0 {
+			return nil, &PaymentPendingError{OrderNumber: record.OrderNumber, Since: record.CreatedAt}
+		}
+
+		p.logger.Info(ctx, "Payment already created for order number, returning existing payment",
+			"order_number", record.OrderNumber, "payment_id", record.PaymentId)
+		return p.PaymentInterface.GetPayment(ctx, record.PaymentId, opts...)
+	}
+
+	// With strict decoding a created payment may come with an *client.UnknownFieldsError.
+	resp, err := p.PaymentInterface.CreatePayment(ctx, payment, opts...)
+	if resp == nil {
+		if notCreated(err) {
+			if releaseErr := p.storage.Release(payment.OrderNumber); releaseErr != nil {
+				p.logger.Error(ctx, "Failed to release order number", "order_number", payment.OrderNumber, "error", releaseErr)
+			}
+		} else {
+			p.logger.Warn(ctx, "Payment creation has unknown outcome, order number stays reserved",
+				"order_number", payment.OrderNumber, "error", err)
+		}
+		return nil, err
+	}
+
+	if completeErr := p.storage.Complete(payment.OrderNumber, resp.Id); completeErr != nil {
+		p.logger.Error(ctx, "Failed to store created payment", "order_number", payment.OrderNumber, "payment_id", resp.Id, "error", completeErr)
+	}
+
+	return resp, err
+}
+
+// reserve reserves the order number, taking over a pending reservation older than
+// the pending expiry.
+func (p *idempotentPayment) reserve(ctx context.Context, record storage.PaymentRecord) (storage.PaymentRecord, bool, error) {
+	existing, reserved, err := p.storage.Reserve(record)
+	if err != nil || reserved || existing.PaymentId != 0 || p.pendingExpiry <= 0 || time.Since(existing.CreatedAt) < p.pendingExpiry {
+		return existing, reserved, err
+	}
+
+	p.logger.Warn(ctx, "Pending order number expired, creating the payment again",
+		"order_number", existing.OrderNumber, "pending_since", existing.CreatedAt)
+	if err := p.storage.Release(existing.OrderNumber); err != nil {
+		return existing, false, err
+	}
+	return p.storage.Reserve(record)
+}
+
+// ResolvePendingPayment records paymentId as the payment created for an order number
+// left pending by a creation with unknown outcome, see PaymentPendingError. The payment
+// is looked up first and must belong to the order number.
+func (p *idempotentPayment) ResolvePendingPayment(ctx context.Context, orderNumber string, paymentId int64, opts ...CallOption) (*paymentApi.PaymentResponse, error) {
+	record, ok, err := p.storage.Get(orderNumber)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("order number %q is not reserved", orderNumber)
+	}
+	if record.PaymentId != 0 && record.PaymentId != paymentId {
+		return nil, &IdempotencyConflictError{OrderNumber: orderNumber, PaymentId: record.PaymentId}
+	}
+
+	resp, err := p.PaymentInterface.GetPayment(ctx, paymentId, opts...)
+	if resp == nil {
+		return nil, err
+	}
+	if resp.OrderNumber != orderNumber {
+		return nil, fmt.Errorf("payment %d belongs to order number %q, not %q", paymentId, resp.OrderNumber, orderNumber)
+	}
+
+	if completeErr := p.storage.Complete(orderNumber, paymentId); completeErr != nil {
+		return nil, completeErr
+	}
+	return resp, err
+}
+
+// ReleasePendingPayment frees an order number left pending by a creation with unknown
+// outcome after it was verified that GoPay did not create the payment, see
+// PaymentPendingError. Order numbers with a created payment are not released.
+func (p *idempotentPayment) ReleasePendingPayment(orderNumber string) error {
+	record, ok, err := p.storage.Get(orderNumber)
+	if err != nil || !ok {
+		return err
+	}
+	if record.PaymentId != 0 {
+		return fmt.Errorf("order number %q already has payment %d", orderNumber, record.PaymentId)
+	}
+	return p.storage.Release(orderNumber)
+}
+
+// ResolvePendingPayment fails with ErrNoIdempotencyStorage, order numbers are reserved
+// only with config.WithIdempotencyStorage.
+func (p *payment) ResolvePendingPayment(context.Context, string, int64, ...CallOption) (*paymentApi.PaymentResponse, error) {
+	return nil, ErrNoIdempotencyStorage
+}
+
+// ReleasePendingPayment fails with ErrNoIdempotencyStorage, order numbers are reserved
+// only with config.WithIdempotencyStorage.
+func (p *payment) ReleasePendingPayment(string) error {
+	return ErrNoIdempotencyStorage
+}
+
+// notCreated reports whether the error proves that GoPay did not create the payment,
+// either because it was never sent or because GoPay rejected it. Server errors may come
+// from a proxy after the payment was created, so they are treated as unknown outcome.
+func notCreated(err error) bool {
+	var (
+		statusErr *client.StatusError
+		buildErr  *client.BuildError
+		authErr   *gopayHttp.AuthError
+		waitErr   *gopayHttp.RateLimitWaitError
+		validErr  *paymentApi.ValidationError
+	)
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode < 500
+	}
+	return errors.As(err, &buildErr) ||
+		errors.As(err, &authErr) ||
+		errors.As(err, &waitErr) ||
+		errors.As(err, &validErr) ||
+		errors.Is(err, gopayHttp.ErrCircuitOpen) ||
+		errors.Is(err, ErrClosed)
+}
+
+func paymentFingerprint(payment *paymentApi.Payment) (string, error) {
+	data, err := json.Marshal(payment)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode payment: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,288 @@
+package gopay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	paymentApi "github.com/tkliner/go-gopay/apis/payment"
+	"github.com/tkliner/go-gopay/client"
+	"github.com/tkliner/go-gopay/client/config"
+	gopayHttp "github.com/tkliner/go-gopay/client/http"
+	"github.com/tkliner/go-gopay/client/storage"
+	"github.com/tkliner/go-gopay/client/storage/file"
+	"github.com/tkliner/go-gopay/client/storage/inmemory"
+)
+
+func testPayment(orderNumber string) *paymentApi.Payment {
+	return &paymentApi.Payment{
+		Amount:      10000,
+		Currency:    paymentApi.CZK,
+		OrderNumber: orderNumber,
+		Callback: &paymentApi.Callback{
+			Url:          "https://eshop.example/return",
+			Notification: "https://eshop.example/notify",
+		},
+	}
+}
+
+func TestIdempotentCreatePayment(t *testing.T) {
+	var created atomic.Int32
+
+	srv := newTestGateway(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodPost {
+			if created.Add(1) == 1 {
+				w.Write([]byte(`{"id": 1001, "order_number": "A-1", "state": "CREATED"}`))
+				return
+			}
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"errors": [{"error_code": 110}]}`))
+			return
+		}
+		w.Write([]byte(`{"id": 1001, "order_number": "A-1", "state": "PAYMENT_METHOD_CHOSEN"}`))
+	})
+
+	stores := map[string]func() (Clienter, error){
+		"inmemory": func() (Clienter, error) {
+			return newTestClient(t, srv, config.WithIdempotencyStorage(inmemory.NewInMemoryIdempotencyStorage())), nil
+		},
+		"file": func() (Clienter, error) {
+			s, err := file.NewFileIdempotencyStorage(filepath.Join(t.TempDir(), "payments.json"))
+			if err != nil {
+				return nil, err
+			}
+			return newTestClient(t, srv, config.WithIdempotencyStorage(s)), nil
+		},
+	}
+
+	for name, newClient := range stores {
+		t.Run(name, func(t *testing.T) {
+			created.Store(0)
+			c, err := newClient()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			first, err := c.Payment().CreatePayment(context.Background(), testPayment("A-1"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			retry, err := c.Payment().CreatePayment(context.Background(), testPayment("A-1"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if retry.Id != first.Id || created.Load() != 1 {
+				t.Errorf("retry created a new payment: %d != %d, creations %d", retry.Id, first.Id, created.Load())
+			}
+
+			changed := testPayment("A-1")
+			changed.Amount = 20000
+			_, err = c.Payment().CreatePayment(context.Background(), changed)
+
+			var conflict *IdempotencyConflictError
+			if !errors.As(err, &conflict) || conflict.PaymentId != 1001 {
+				t.Errorf("expected conflict, got %v", err)
+			}
+
+			// Rejected creation releases the order number so that it can be retried.
+			if _, err := c.Payment().CreatePayment(context.Background(), testPayment("B-1")); err == nil {
+				t.Fatal("expected rejection")
+			}
+			if _, err := c.Payment().CreatePayment(context.Background(), testPayment("B-1")); err == nil || errors.As(err, new(*PaymentPendingError)) {
+				t.Errorf("expected a new attempt to be sent, got %v", err)
+			}
+		})
+	}
+}
+
+func TestIdempotentCreatePaymentUnknownFields(t *testing.T) {
+	srv := newTestGateway(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id": 1001, "order_number": "A-1", "state": "CREATED", "loyalty": {}}`))
+	})
+	c := newTestClient(t, srv, config.WithStrictDecoding(), config.WithIdempotencyStorage(inmemory.NewInMemoryIdempotencyStorage()))
+
+	resp, err := c.Payment().CreatePayment(context.Background(), testPayment("A-1"))
+	if !errors.As(err, new(*client.UnknownFieldsError)) || resp == nil || resp.Id != 1001 {
+		t.Fatalf("expected created payment with unknown fields error, got %+v, %v", resp, err)
+	}
+
+	// The payment was created, so a retry looks it up instead of reporting it pending.
+	retry, err := c.Payment().CreatePayment(context.Background(), testPayment("A-1"))
+	if retry == nil || retry.Id != 1001 || errors.As(err, new(*PaymentPendingError)) {
+		t.Errorf("expected existing payment, got %+v, %v", retry, err)
+	}
+}
+
+func TestIdempotentCreatePaymentCircuitOpen(t *testing.T) {
+	var created atomic.Int32
+	srv := newTestGateway(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		created.Add(1)
+		w.Write([]byte(`{"id": 1001, "order_number": "A-1", "state": "CREATED"}`))
+	})
+
+	c := newTestClient(t, srv,
+		config.WithIdempotencyStorage(inmemory.NewInMemoryIdempotencyStorage()),
+		config.WithCircuitBreaker(config.CircuitBreaker{MinRequests: 1, OpenTimeout: 50 * time.Millisecond}),
+	)
+
+	// A failing status lookup opens the breaker.
+	c.Payment().GetPayment(context.Background(), 1001)
+
+	if _, err := c.Payment().CreatePayment(context.Background(), testPayment("A-1")); !errors.Is(err, gopayHttp.ErrCircuitOpen) {
+		t.Fatalf("expected open circuit, got %v", err)
+	}
+
+	// Nothing was sent, so the order number is free once the breaker lets calls through.
+	time.Sleep(60 * time.Millisecond)
+	resp, err := c.Payment().CreatePayment(context.Background(), testPayment("A-1"))
+	if err != nil || resp.Id != 1001 || created.Load() != 1 {
+		t.Errorf("expected the payment to be created, got %+v, %v", resp, err)
+	}
+}
+
+func TestNotCreated(t *testing.T) {
+	tests := map[string]struct {
+		err  error
+		want bool
+	}{
+		"rejected":     {&client.StatusError{StatusCode: http.StatusBadRequest}, true},
+		"server error": {&client.StatusError{StatusCode: http.StatusBadGateway}, false},
+		"timeout":      {context.DeadlineExceeded, false},
+		"build":        {&client.BuildError{Err: errors.New("missing value")}, true},
+		"auth":         {&gopayHttp.AuthError{Err: errors.New("invalid_client")}, true},
+		"circuit open": {fmt.Errorf("request failed: %w", &gopayHttp.CircuitOpenError{}), true},
+		"closed":       {fmt.Errorf("request failed: %w", ErrClosed), true},
+		"rate limit":   {&gopayHttp.RateLimitWaitError{Err: context.Canceled}, true},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := notCreated(tt.err); got != tt.want {
+				t.Errorf("notCreated(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolvePendingPayment(t *testing.T) {
+	var creations atomic.Int32
+	srv := newTestGateway(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method != http.MethodPost:
+			w.Write([]byte(`{"id": 1001, "order_number": "A-1", "state": "CREATED"}`))
+		case creations.Add(1) == 1:
+			// The payment may have been created behind a failing proxy.
+			w.WriteHeader(http.StatusBadGateway)
+		default:
+			w.Write([]byte(`{"id": 1002, "order_number": "B-1", "state": "CREATED"}`))
+		}
+	})
+
+	pending := func(t *testing.T, c Clienter, orderNumber string) {
+		t.Helper()
+		if _, err := c.Payment().CreatePayment(context.Background(), testPayment(orderNumber)); err == nil {
+			t.Fatal("expected the creation to fail")
+		}
+		if _, err := c.Payment().CreatePayment(context.Background(), testPayment(orderNumber)); !errors.As(err, new(*PaymentPendingError)) {
+			t.Fatalf("expected pending error, got %v", err)
+		}
+	}
+
+	t.Run("resolve", func(t *testing.T) {
+		creations.Store(0)
+		c := newTestClient(t, srv, config.WithIdempotencyStorage(inmemory.NewInMemoryIdempotencyStorage()))
+		pending(t, c, "A-1")
+
+		if _, err := c.Payment().ResolvePendingPayment(context.Background(), "B-1", 1001); err == nil {
+			t.Error("expected error for an order number that is not reserved")
+		}
+		if _, err := c.Payment().ResolvePendingPayment(context.Background(), "A-1", 1001); err != nil {
+			t.Fatal(err)
+		}
+		resp, err := c.Payment().CreatePayment(context.Background(), testPayment("A-1"))
+		if err != nil || resp.Id != 1001 || creations.Load() != 1 {
+			t.Errorf("expected resolved payment, got %+v, %v", resp, err)
+		}
+		if err := c.Payment().ReleasePendingPayment("A-1"); err == nil {
+			t.Error("expected created payment not to be released")
+		}
+	})
+
+	t.Run("release", func(t *testing.T) {
+		creations.Store(0)
+		c := newTestClient(t, srv, config.WithIdempotencyStorage(inmemory.NewInMemoryIdempotencyStorage()))
+		pending(t, c, "B-1")
+
+		if err := c.Payment().ReleasePendingPayment("B-1"); err != nil {
+			t.Fatal(err)
+		}
+		resp, err := c.Payment().CreatePayment(context.Background(), testPayment("B-1"))
+		if err != nil || resp.Id != 1002 {
+			t.Errorf("expected a new payment, got %+v, %v", resp, err)
+		}
+	})
+
+	t.Run("expiry", func(t *testing.T) {
+		creations.Store(0)
+		c := newTestClient(t, srv,
+			config.WithIdempotencyStorage(inmemory.NewInMemoryIdempotencyStorage()),
+			config.WithPendingExpiry(50*time.Millisecond),
+		)
+		pending(t, c, "B-1")
+
+		time.Sleep(60 * time.Millisecond)
+		resp, err := c.Payment().CreatePayment(context.Background(), testPayment("B-1"))
+		if err != nil || resp.Id != 1002 {
+			t.Errorf("expected expired reservation to be taken over, got %+v, %v", resp, err)
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		c := newTestClient(t, srv)
+		if err := c.Payment().ReleasePendingPayment("A-1"); !errors.Is(err, ErrNoIdempotencyStorage) {
+			t.Errorf("expected ErrNoIdempotencyStorage, got %v", err)
+		}
+	})
+}
+
+func TestFileIdempotencyStoragePersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "payments.json")
+
+	s, err := file.NewFileIdempotencyStorage(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := testReserve(s, "C-1"); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := file.NewFileIdempotencyStorage(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	record, ok, err := reopened.Get("C-1")
+	if err != nil || !ok || record.PaymentId != 42 {
+		t.Errorf("record not persisted: %+v, %v, %v", record, ok, err)
+	}
+}
+
+func testReserve(s *file.FileIdempotencyStorage, orderNumber string) error {
+	if _, _, err := s.Reserve(storage.PaymentRecord{OrderNumber: orderNumber, Fingerprint: "x"}); err != nil {
+		return err
+	}
+	return s.Complete(orderNumber, 42)
+}
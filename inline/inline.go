@@ -0,0 +1,138 @@
+// Package inline renders the GoPay inline gateway, which opens the payment form in an
+// overlay of the e-shop page instead of redirecting the customer.
+package inline
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/url"
+	"strings"
+
+	paymentApi "github.com/tkliner/go-gopay/apis/payment"
+	"github.com/tkliner/go-gopay/client/config"
+)
+
+// Environment selects the GoPay gateway serving the embed script.
+type Environment string
+
+const (
+	Sandbox    Environment = config.SandboxGatewayURL
+	Production Environment = config.ProductionGatewayURL
+
+	embedScriptPath = "/gp-gw/js/embed.js"
+)
+
+// EnvironmentFor returns the environment matching the client configuration.
+func EnvironmentFor(cfg *config.Config) Environment {
+	if cfg.IsProduction {
+		return Production
+	}
+	return Sandbox
+}
+
+// ScriptURL returns the URL of the embed script.
+func (e Environment) ScriptURL() string {
+	return string(e) + embedScriptPath
+}
+
+// Gateway is the data needed to open the inline gateway. It is JSON encodable for
+// single page applications, which load ScriptURL and submit a form to GatewayURL.
+type Gateway struct {
+	PaymentId  int64  `json:"payment_id"`
+	GatewayURL string `json:"gateway_url"`
+	ScriptURL  string `json:"script_url"`
+}
+
+// New returns the inline gateway of a created payment. The gateway URL must belong to
+// the environment, so that the page never posts the customer to a foreign host.
+func New(payment *paymentApi.PaymentResponse, env Environment) (*Gateway, error) {
+	if payment == nil || payment.GatewayURL == "" {
+		return nil, errors.New("inline: payment has no gateway URL")
+	}
+
+	gw, err := url.Parse(payment.GatewayURL)
+	if err != nil {
+		return nil, fmt.Errorf("inline: invalid gateway URL: %w", err)
+	}
+	if gw.Scheme+"://"+gw.Host != string(env) {
+		return nil, fmt.Errorf("inline: gateway URL %s does not belong to %s", payment.GatewayURL, env)
+	}
+
+	return &Gateway{
+		PaymentId:  payment.Id,
+		GatewayURL: payment.GatewayURL,
+		ScriptURL:  env.ScriptURL(),
+	}, nil
+}
+
+// FormOptions customizes the rendered payment button.
+type FormOptions struct {
+	// ButtonLabel is the text of the button, "Zaplatit" by default.
+	ButtonLabel string
+	// ButtonClass is the CSS class of the button.
+	ButtonClass string
+	// Nonce is added to the script tag for pages with a nonce based CSP.
+	Nonce string
+}
+
+var formTemplate = template.Must(template.New("inline").Parse(
+	`<form action="{{.GatewayURL}}" method="post" id="gopay-payment-button">` +
+		`<button name="pay" type="submit"{{if .ButtonClass}} class="{{.ButtonClass}}"{{end}}>{{.ButtonLabel}}</button>` +
+		`<script type="text/javascript" src="{{.ScriptURL}}"{{if .Nonce}} nonce="{{.Nonce}}"{{end}}></script>` +
+		`</form>`))
+
+// HTML renders the payment form for use in html/template pages.
+func (g *Gateway) HTML(opts FormOptions) (template.HTML, error) {
+	if opts.ButtonLabel == "" {
+		opts.ButtonLabel = "Zaplatit"
+	}
+
+	var buf bytes.Buffer
+	err := formTemplate.Execute(&buf, struct {
+		*Gateway
+		FormOptions
+	}{g, opts})
+	if err != nil {
+		return "", fmt.Errorf("inline: failed to render form: %w", err)
+	}
+
+	return template.HTML(buf.String()), nil
+}
+
+// CSP lists the Content-Security-Policy sources the inline gateway needs.
+type CSP struct {
+	ScriptSrc  []string
+	FrameSrc   []string
+	FormAction []string
+}
+
+// ContentSecurityPolicy returns the sources required by the inline gateway of env.
+func ContentSecurityPolicy(env Environment) CSP {
+	origin := []string{string(env)}
+	return CSP{
+		ScriptSrc:  origin,
+		FrameSrc:   origin,
+		FormAction: origin,
+	}
+}
+
+// String formats the directives for the Content-Security-Policy header. Merge them
+// with the directives of the page, e.g. "script-src 'self' " + strings.Join(csp.ScriptSrc, " ").
+func (c CSP) String() string {
+	var directives []string
+	for _, d := range []struct {
+		name    string
+		sources []string
+	}{
+		{"script-src", c.ScriptSrc},
+		{"frame-src", c.FrameSrc},
+		{"form-action", c.FormAction},
+	} {
+		if len(d.sources) > 0 {
+			directives = append(directives, d.name+" "+strings.Join(d.sources, " "))
+		}
+	}
+	return strings.Join(directives, "; ")
+}
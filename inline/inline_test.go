@@ -0,0 +1,59 @@
+package inline
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	paymentApi "github.com/tkliner/go-gopay/apis/payment"
+)
+
+func TestGatewayHTML(t *testing.T) {
+	g, err := New(&paymentApi.PaymentResponse{
+		Id:         3000006529,
+		GatewayURL: `https://gw.sandbox.gopay.com/gw/v3/abc"><script>`,
+	}, Sandbox)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	html, err := g.HTML(FormOptions{ButtonLabel: "Pay <now>", Nonce: "n0nce"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := string(html)
+	if strings.Contains(s, `"><script>`) || strings.Contains(s, "<now>") {
+		t.Errorf("values are not escaped: %s", s)
+	}
+	for _, want := range []string{
+		`id="gopay-payment-button"`,
+		`src="https://gw.sandbox.gopay.com/gp-gw/js/embed.js"`,
+		`nonce="n0nce"`,
+	} {
+		if !strings.Contains(s, want) {
+			t.Errorf("missing %s in %s", want, s)
+		}
+	}
+
+	data, _ := json.Marshal(g)
+	if !strings.Contains(string(data), `"script_url":"https://gw.sandbox.gopay.com/gp-gw/js/embed.js"`) {
+		t.Errorf("unexpected JSON %s", data)
+	}
+}
+
+func TestNewRejectsForeignGateway(t *testing.T) {
+	for _, gw := range []string{"https://gw.sandbox.gopay.com/gw/v3/abc", "https://evil.example/gw", ""} {
+		if _, err := New(&paymentApi.PaymentResponse{GatewayURL: gw}, Production); err == nil {
+			t.Errorf("expected error for %q", gw)
+		}
+	}
+}
+
+func TestContentSecurityPolicy(t *testing.T) {
+	got := ContentSecurityPolicy(Production).String()
+	want := "script-src https://gate.gopay.cz; frame-src https://gate.gopay.cz; form-action https://gate.gopay.cz"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
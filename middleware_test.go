@@ -0,0 +1,108 @@
+package gopay
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+
+	"github.com/tkliner/go-gopay/client/config"
+)
+
+type recordingTransport struct {
+	paths []string
+}
+
+func (rt *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rt.paths = append(rt.paths, req.URL.Path)
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestNewWithClientAuthenticates(t *testing.T) {
+	var authorization string
+	srv := newTestGateway(t, func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(paymentStatusJSON))
+	})
+
+	transport := &recordingTransport{}
+	c, err := NewWithClient(config.NewConfig(
+		config.WithGatewayURL(srv.URL),
+		config.WithCredentials(8123456789, "client", "secret"),
+	), &http.Client{Transport: transport})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close(context.Background())
+
+	if _, err := c.Payment().GetPayment(context.Background(), 3000006529); err != nil {
+		t.Fatalf("GetPayment: %v", err)
+	}
+
+	if authorization != "Bearer test-token" {
+		t.Errorf("unexpected authorization %q", authorization)
+	}
+	if len(transport.paths) != 2 || transport.paths[0] != "/api/oauth2/token" {
+		t.Errorf("base transport not used for all requests: %v", transport.paths)
+	}
+}
+
+func TestMiddlewareOrder(t *testing.T) {
+	var attempts atomic.Int32
+	srv := newTestGateway(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer test-token" {
+			t.Errorf("attempt without token")
+		}
+		if attempts.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(paymentStatusJSON))
+	})
+
+	var order []string
+	var loggedAuthorization string
+
+	logging := func(next http.RoundTripper) http.RoundTripper {
+		return roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			order = append(order, "logging")
+			loggedAuthorization = req.Header.Get("Authorization")
+			return next.RoundTrip(req)
+		})
+	}
+	retry := func(next http.RoundTripper) http.RoundTripper {
+		return roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			order = append(order, "retry")
+			resp, err := next.RoundTrip(req)
+			if err == nil && resp.StatusCode == http.StatusServiceUnavailable {
+				resp.Body.Close()
+				return next.RoundTrip(req)
+			}
+			return resp, err
+		})
+	}
+
+	c := newTestClient(t, srv, config.WithMiddleware(logging, retry))
+
+	if _, err := c.Payment().GetPayment(context.Background(), 3000006529); err != nil {
+		t.Fatalf("GetPayment: %v", err)
+	}
+
+	if len(order) != 2 || order[0] != "logging" || order[1] != "retry" {
+		t.Errorf("unexpected order %v", order)
+	}
+	if loggedAuthorization != "" {
+		t.Errorf("middleware saw the token %q", loggedAuthorization)
+	}
+	if attempts.Load() != 2 {
+		t.Errorf("expected 2 attempts, got %d", attempts.Load())
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
@@ -0,0 +1,46 @@
+package gopay
+
+import (
+	"time"
+
+	"github.com/tkliner/go-gopay/client"
+)
+
+const headerIdempotencyKey = "Idempotency-Key"
+
+// CallOption customizes a single API call.
+type CallOption func(r *client.Request)
+
+// WithHeader sets a header of the call.
+func WithHeader(key, value string) CallOption {
+	return func(r *client.Request) {
+		r.SetHeader(key, value)
+	}
+}
+
+// WithQueryParam adds a query parameter to the call.
+func WithQueryParam(key, value string) CallOption {
+	return func(r *client.Request) {
+		r.Param(key, value)
+	}
+}
+
+// WithTimeout limits the duration of the call, in addition to the client timeout.
+func WithTimeout(d time.Duration) CallOption {
+	return func(r *client.Request) {
+		r.Timeout(d)
+	}
+}
+
+// WithIdempotencyKey sends the key in the Idempotency-Key header, so that proxies
+// and the gateway can recognize a retried call.
+func WithIdempotencyKey(key string) CallOption {
+	return WithHeader(headerIdempotencyKey, key)
+}
+
+func applyOptions(r *client.Request, opts []CallOption) *client.Request {
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
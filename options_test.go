@@ -0,0 +1,38 @@
+package gopay
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestCallOptions(t *testing.T) {
+	var got *http.Request
+	srv := newTestGateway(t, func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(paymentStatusJSON))
+	})
+
+	_, err := newTestClient(t, srv).Payment().GetPayment(context.Background(), 3000006529,
+		WithHeader("X-Request-Id", "req-1"),
+		WithQueryParam("lang", "CS"),
+		WithIdempotencyKey("key-1"),
+	)
+	if err != nil {
+		t.Fatalf("GetPayment: %v", err)
+	}
+
+	if got.URL.Path != "/api/payments/payment/3000006529" {
+		t.Errorf("unexpected path %s", got.URL.Path)
+	}
+	if got.URL.Query().Get("lang") != "CS" {
+		t.Errorf("missing query parameter: %s", got.URL.RawQuery)
+	}
+	if got.Header.Get("X-Request-Id") != "req-1" {
+		t.Errorf("missing header")
+	}
+	if got.Header.Get("Idempotency-Key") != "key-1" {
+		t.Errorf("missing idempotency key")
+	}
+}
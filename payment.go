@@ -2,18 +2,32 @@ package gopay
 
 import (
 	"context"
-	"fmt"
+	"net/url"
+	"strconv"
 
 	paymentApi "github.com/tkliner/go-gopay/apis/payment"
 	"github.com/tkliner/go-gopay/client"
+	"github.com/tkliner/go-gopay/client/config"
 )
 
 type PaymentGetter interface {
 	Payment() PaymentInterface
 }
 
+// PaymentInterface is the payments API. CreatePayment needs a payment-create token,
+// the other methods a payment-all token.
 type PaymentInterface interface {
-	GetPayment(ctx context.Context, id int64) (payment *paymentApi.PaymentResponse, err error)
+	CreatePayment(ctx context.Context, payment *paymentApi.Payment, opts ...CallOption) (*paymentApi.PaymentResponse, error)
+	// ResolvePendingPayment records the payment created for an order number left pending.
+	ResolvePendingPayment(ctx context.Context, orderNumber string, paymentId int64, opts ...CallOption) (*paymentApi.PaymentResponse, error)
+	// ReleasePendingPayment frees an order number left pending whose payment was not created.
+	ReleasePendingPayment(orderNumber string) error
+	GetPayment(ctx context.Context, id int64, opts ...CallOption) (payment *paymentApi.PaymentResponse, err error)
+	RefundPayment(ctx context.Context, id int64, amount paymentApi.Amount, opts ...CallOption) (*paymentApi.OperationResponse, error)
+	CapturePayment(ctx context.Context, id int64, opts ...CallOption) (*paymentApi.OperationResponse, error)
+	VoidAuthorization(ctx context.Context, id int64, opts ...CallOption) (*paymentApi.OperationResponse, error)
+	GetPayments(ctx context.Context, ids []int64, opts BulkOptions) <-chan BulkResult
+	WaitForState(ctx context.Context, id int64, targetStates []paymentApi.PaymentState, opts ...CallOption) (*WaitResult, error)
 }
 
 type payment struct {
@@ -26,15 +40,66 @@ func newPayment(c client.Interface) PaymentInterface {
 	}
 }
 
-func (p *payment) GetPayment(ctx context.Context, id int64) (payment *paymentApi.PaymentResponse, err error) {
-	resp := &paymentApi.PaymentResponse{}	
-	req := p.client.Get().Resource(fmt.Sprintf("%s/%d", pathPayment, id))
-	
-	err = req.Do(ctx).Convert(resp)
-
-	if err != nil {
+// CreatePayment validates the payment and creates it on the gateway. Validation
+// failures are returned as *paymentApi.ValidationError without contacting GoPay.
+func (p *payment) CreatePayment(ctx context.Context, payment *paymentApi.Payment, opts ...CallOption) (*paymentApi.PaymentResponse, error) {
+	if err := payment.Validate(); err != nil {
 		return nil, err
 	}
 
-	return resp, nil
-}
\ No newline at end of file
+	req := p.client.Post().
+		Resource(pathPayment).
+		Scope(config.TokenScopeCreatePayment).
+		JSONBody(payment)
+
+	resp, _, err := client.Do[paymentApi.PaymentResponse](ctx, applyOptions(req, opts))
+	return resp, err
+}
+
+func (p *payment) GetPayment(ctx context.Context, id int64, opts ...CallOption) (payment *paymentApi.PaymentResponse, err error) {
+	req := p.client.Get().
+		Resource(pathPayment+"/{id}").
+		PathParam("id", id).
+		Scope(config.TokenScopeAll)
+
+	payment, _, err = client.Do[paymentApi.PaymentResponse](ctx, applyOptions(req, opts))
+	return payment, err
+}
+
+// RefundPayment refunds the amount, in minor units, of a paid payment. A partial
+// refund leaves the payment PARTIALLY_REFUNDED.
+func (p *payment) RefundPayment(ctx context.Context, id int64, amount paymentApi.Amount, opts ...CallOption) (*paymentApi.OperationResponse, error) {
+	form := url.Values{}
+	form.Set("amount", strconv.FormatInt(int64(amount), 10))
+
+	req := p.client.Post().
+		Resource(pathPayment+"/{id}/refund").
+		PathParam("id", id).
+		Scope(config.TokenScopeAll).
+		FormBody(form)
+
+	resp, _, err := client.Do[paymentApi.OperationResponse](ctx, applyOptions(req, opts))
+	return resp, err
+}
+
+// CapturePayment charges a pre-authorized payment.
+func (p *payment) CapturePayment(ctx context.Context, id int64, opts ...CallOption) (*paymentApi.OperationResponse, error) {
+	return p.operation(ctx, id, "capture", opts)
+}
+
+// VoidAuthorization cancels a pre-authorized payment and releases the blocked funds.
+func (p *payment) VoidAuthorization(ctx context.Context, id int64, opts ...CallOption) (*paymentApi.OperationResponse, error) {
+	return p.operation(ctx, id, "void-authorization", opts)
+}
+
+func (p *payment) operation(ctx context.Context, id int64, operation string, opts []CallOption) (*paymentApi.OperationResponse, error) {
+	req := p.client.Post().
+		Resource(pathPayment+"/{id}/{operation}").
+		PathParam("id", id).
+		PathParam("operation", operation).
+		Scope(config.TokenScopeAll).
+		ContentType(client.ContentTypeForm)
+
+	resp, _, err := client.Do[paymentApi.OperationResponse](ctx, applyOptions(req, opts))
+	return resp, err
+}
@@ -2,29 +2,141 @@ package gopay
 
 import (
 	"context"
-	"fmt"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
+	paymentApi "github.com/tkliner/go-gopay/apis/payment"
+	"github.com/tkliner/go-gopay/client"
 	"github.com/tkliner/go-gopay/client/config"
 )
 
-func TestMock( _ *testing.T) {
+func TestCreatePaymentValidatesBeforeSending(t *testing.T) {
 	cfg := config.NewConfig(
-		config.WithGatewayURL("https://gw.sandbox.gopay.com"),
+		config.WithGatewayURL("https://gw.invalid"),
 		config.WithCredentials(8836046164, "1253288454", "Cdf5ChEA"),
 	)
 
 	client, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = client.Payment().CreatePayment(context.Background(), &paymentApi.Payment{
+		Amount:   1000,
+		Currency: paymentApi.CZK,
+	})
+
+	var verr *paymentApi.ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("expected validation error, got %v", err)
+	}
+	if verr.Field("order_number") == nil || verr.Field("callback") == nil {
+		t.Errorf("missing field errors: %v", verr)
+	}
+}
 
+// newTestGateway starts a fake GoPay gateway. Token requests are answered automatically,
+// all other requests are passed to the handler.
+func newTestGateway(t *testing.T, handler http.HandlerFunc) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/oauth2/token" {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"token_type":"bearer","access_token":"test-token","expires_in":1800}`))
+			return
+		}
+		handler(w, r)
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func newTestClient(t *testing.T, srv *httptest.Server, opts ...config.Option) Clienter {
+	t.Helper()
+
+	opts = append([]config.Option{
+		config.WithGatewayURL(srv.URL),
+		config.WithCredentials(8123456789, "client", "secret"),
+	}, opts...)
+
+	client, err := New(config.NewConfig(opts...))
 	if err != nil {
-		panic(err)
+		t.Fatal(err)
 	}
+	t.Cleanup(func() { client.Close(context.Background()) })
+
+	return client
+}
 
-	resp, err := client.Payment().GetPayment(context.TODO(), 3283981064)
+func TestCreatePaymentCallbackWireFormat(t *testing.T) {
+	var body map[string]json.RawMessage
+	srv := newTestGateway(t, func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id": 1001, "state": "CREATED"}`))
+	})
 
+	if _, err := newTestClient(t, srv).Payment().CreatePayment(context.Background(), testPayment("A-1")); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `{"return_url":"https://eshop.example/return","notification_url":"https://eshop.example/notify"}`
+	if got := string(body["callback"]); got != want {
+		t.Errorf("unexpected callback %s", got)
+	}
+}
+
+const paymentStatusJSON = `{
+	"id": 3000006529,
+	"order_number": "001",
+	"state": "PAID",
+	"amount": 139950,
+	"currency": "CZK",
+	"payment_instrument": "PAYMENT_CARD",
+	"payer": {
+		"allowed_payment_instruments": ["PAYMENT_CARD", "BANK_ACCOUNT"],
+		"default_payment_instrument": "PAYMENT_CARD",
+		"payment_card": {"card_number": "444444******4448", "card_expiration": "1909", "card_brand": "VISA", "3ds_result": "Y/Y"},
+		"contact": {"first_name": "Zbyněk", "last_name": "Žák", "email": "test@test.cz", "country_code": "CZE", "nickname": "zz"}
+	},
+	"target": {"type": "ACCOUNT", "goid": 8123456789},
+	"additional_params": [{"name": "invoicenumber", "value": "2015001003"}],
+	"lang": "cs",
+	"gw_url": "https://gw.sandbox.gopay.com/gw/v3/bCcvmwTKK5hrJx2aGG8ZnFyBJhAvF",
+	"loyalty": {"points": 10}
+}`
+
+func TestGetPaymentStrictDecoding(t *testing.T) {
+	srv := newTestGateway(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(paymentStatusJSON))
+	})
+
+	resp, err := newTestClient(t, srv).Payment().GetPayment(context.Background(), 3000006529)
 	if err != nil {
-		panic(err)
+		t.Fatalf("lenient decoding failed: %v", err)
 	}
+	if resp.State != paymentApi.StatePaid || resp.Payer.PaymentCard.ThreeDSResult != "Y/Y" || resp.Target.GoId != 8123456789 ||
+		resp.GatewayURL != "https://gw.sandbox.gopay.com/gw/v3/bCcvmwTKK5hrJx2aGG8ZnFyBJhAvF" {
+		t.Errorf("unexpected response %+v", resp)
+	}
+
+	resp, err = newTestClient(t, srv, config.WithStrictDecoding()).Payment().GetPayment(context.Background(), 3000006529)
 
-	fmt.Printf("Response: %+v\n", resp)
-}
\ No newline at end of file
+	var unknown *client.UnknownFieldsError
+	if !errors.As(err, &unknown) {
+		t.Fatalf("expected *client.UnknownFieldsError, got %v", err)
+	}
+	if got := strings.Join(unknown.Fields, ","); got != "loyalty,payer.contact.nickname" {
+		t.Errorf("unexpected unknown fields %s", got)
+	}
+	if resp == nil || resp.State != paymentApi.StatePaid {
+		t.Errorf("payment not returned with unknown fields: %+v", resp)
+	}
+}
@@ -0,0 +1,50 @@
+package gopay
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/tkliner/go-gopay/client/config"
+	gopayHttp "github.com/tkliner/go-gopay/client/http"
+)
+
+func TestRateLimitStats(t *testing.T) {
+	srv := newTestGateway(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id": 1, "state": "CREATED"}`))
+	})
+
+	if stats := newTestClient(t, srv).RateLimitStats(); stats != nil {
+		t.Errorf("expected no stats without rate limits, got %v", stats)
+	}
+
+	c := newTestClient(t, srv, config.WithRateLimits(config.RateLimits{
+		PaymentRead: config.RateLimit{Rate: 0.1, Burst: 1},
+	}))
+	if _, err := c.Payment().GetPayment(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		_, err := c.Payment().GetPayment(ctx, 1)
+		done <- err
+	}()
+
+	deadline := time.Now().Add(time.Second)
+	for c.RateLimitStats()[gopayHttp.EndpointPaymentRead].QueueDepth != 1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("waiting request not reported: %+v", c.RateLimitStats())
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	cancel()
+	<-done
+	if depth := c.RateLimitStats()[gopayHttp.EndpointPaymentRead].QueueDepth; depth != 0 {
+		t.Errorf("expected empty queue after cancel, got %d", depth)
+	}
+}
@@ -0,0 +1,44 @@
+package gopay
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// Raw sends an authenticated request to an endpoint the library does not wrap.
+// The path is relative to the API prefix, e.g. "/payments/payment/123/card-details",
+// and may contain a query. The token has the configured scope unless ctx requests
+// another one with auth.WithScope.
+//
+// The response is returned as is, whatever its status code, and the caller must
+// close its body. Errors are returned only when no response was received, e.g.
+// ErrClosed, *gopayHttp.AuthError or *gopayHttp.CircuitOpenError.
+func (g *GoPay) Raw(ctx context.Context, method, path string, body io.Reader, header http.Header) (*http.Response, error) {
+	u, err := url.Parse(path)
+	if err != nil {
+		return nil, fmt.Errorf("invalid path %q: %w", path, err)
+	}
+	if u.Scheme != "" || u.Host != "" {
+		return nil, fmt.Errorf("path %q must be relative to the API", path)
+	}
+
+	req := g.client.Method(method).Resource(u.Path)
+	if body != nil {
+		req.Body(body)
+	}
+	for key, values := range u.Query() {
+		for _, value := range values {
+			req.Param(key, value)
+		}
+	}
+	for key, values := range header {
+		for _, value := range values {
+			req.AddHeader(key, value)
+		}
+	}
+
+	return req.DoRaw(ctx)
+}
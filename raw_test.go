@@ -0,0 +1,76 @@
+package gopay
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestRaw(t *testing.T) {
+	var got *http.Request
+	var gotBody string
+	srv := newTestGateway(t, func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		got, gotBody = r, string(data)
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte(`{"result":"ACCEPTED"}`))
+	})
+	c := newTestClient(t, srv)
+
+	header := http.Header{"Content-Type": {"application/x-www-form-urlencoded"}, "X-Trace": {"a", "b"}}
+	resp, err := c.Raw(context.Background(), http.MethodPost, "/payments/payment/1/create-recurrence?lang=CS", strings.NewReader("amount=100"), header)
+	if err != nil {
+		t.Fatalf("Raw: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusAccepted || string(body) != `{"result":"ACCEPTED"}` {
+		t.Errorf("unexpected response %d %s", resp.StatusCode, body)
+	}
+	if got.URL.Path != "/api/payments/payment/1/create-recurrence" || got.URL.Query().Get("lang") != "CS" {
+		t.Errorf("unexpected URL %s", got.URL)
+	}
+	if got.Header.Get("Authorization") != "Bearer test-token" {
+		t.Errorf("request not authenticated")
+	}
+	if got.Header.Get("Content-Type") != "application/x-www-form-urlencoded" || len(got.Header.Values("X-Trace")) != 2 {
+		t.Errorf("headers not passed: %v", got.Header)
+	}
+	if gotBody != "amount=100" {
+		t.Errorf("unexpected body %q", gotBody)
+	}
+}
+
+func TestRawErrorStatus(t *testing.T) {
+	srv := newTestGateway(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	c := newTestClient(t, srv)
+
+	resp, err := c.Raw(context.Background(), http.MethodGet, "/payments/payment/1/unknown", nil, nil)
+	if err != nil {
+		t.Fatalf("Raw: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("unexpected status %d", resp.StatusCode)
+	}
+}
+
+func TestRawErrors(t *testing.T) {
+	srv := newTestGateway(t, func(w http.ResponseWriter, r *http.Request) {})
+	c := newTestClient(t, srv)
+
+	if _, err := c.Raw(context.Background(), http.MethodGet, "https://evil.example/api", nil, nil); err == nil {
+		t.Error("expected error for absolute URL")
+	}
+
+	c.Close(context.Background())
+	if _, err := c.Raw(context.Background(), http.MethodGet, "/payments/payment/1", nil, nil); !errors.Is(err, ErrClosed) {
+		t.Errorf("expected ErrClosed, got %v", err)
+	}
+}
@@ -0,0 +1,161 @@
+// Package returnurl provides an http.Handler for the callback return URL, where the
+// customer lands after leaving the GoPay gateway.
+package returnurl
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/tkliner/go-gopay"
+	paymentApi "github.com/tkliner/go-gopay/apis/payment"
+)
+
+const defaultParam = "id"
+
+// ErrUnknownPayment should be returned by OrderLookup for payment IDs the e-shop
+// does not know. Such requests are served by Options.Rejected.
+var ErrUnknownPayment = errors.New("unknown payment")
+
+// OrderLookup returns the order number the e-shop stored when it created the payment.
+// The handler serves the payment only when it matches the order number reported by GoPay,
+// which protects against tampered or foreign payment IDs in the return URL.
+type OrderLookup func(ctx context.Context, paymentId int64) (orderNumber string, err error)
+
+type Options struct {
+	// Lookup is required, see OrderLookup.
+	Lookup OrderLookup
+	// GoId rejects payments made to other e-shops when set.
+	GoId int64
+	// Routes maps final payment states to their pages, e.g. PAID to the thank-you page.
+	Routes map[paymentApi.PaymentState]http.Handler
+	// Fallback serves final states without a route.
+	Fallback http.Handler
+	// Pending serves payments that are not in a final state yet, e.g. a page which
+	// refreshes itself. Fallback is used when not set.
+	Pending http.Handler
+	// WaitTimeout lets the handler wait for a final state before serving Pending.
+	// Zero disables waiting.
+	WaitTimeout time.Duration
+	// Rejected serves requests with a missing, invalid, foreign or tampered payment ID.
+	// It responds with 404 Not Found when not set.
+	Rejected http.Handler
+	// Error serves failures of the order lookup or the payment status request.
+	// It responds with 502 Bad Gateway when not set.
+	Error func(w http.ResponseWriter, r *http.Request, err error)
+	// Param is the query parameter holding the payment ID, "id" by default.
+	Param string
+}
+
+type handler struct {
+	payments gopay.PaymentInterface
+	opts     Options
+}
+
+type paymentKey struct{}
+
+// NewHandler creates the return URL handler. The verified payment is available to
+// the route handlers through PaymentFromContext.
+func NewHandler(payments gopay.PaymentInterface, opts Options) (http.Handler, error) {
+	if payments == nil {
+		return nil, errors.New("returnurl: payments must not be nil")
+	}
+	if opts.Lookup == nil {
+		return nil, errors.New("returnurl: Lookup is required")
+	}
+	if opts.Fallback == nil {
+		return nil, errors.New("returnurl: Fallback is required")
+	}
+	if opts.Pending == nil {
+		opts.Pending = opts.Fallback
+	}
+	if opts.Rejected == nil {
+		opts.Rejected = http.NotFoundHandler()
+	}
+	if opts.Error == nil {
+		opts.Error = func(w http.ResponseWriter, r *http.Request, err error) {
+			http.Error(w, http.StatusText(http.StatusBadGateway), http.StatusBadGateway)
+		}
+	}
+	if opts.Param == "" {
+		opts.Param = defaultParam
+	}
+
+	return &handler{payments: payments, opts: opts}, nil
+}
+
+// PaymentFromContext returns the verified payment passed to the route handlers.
+func PaymentFromContext(ctx context.Context) (*paymentApi.PaymentResponse, bool) {
+	p, ok := ctx.Value(paymentKey{}).(*paymentApi.PaymentResponse)
+	return p, ok
+}
+
+// Redirect returns a route handler redirecting the customer to url.
+func Redirect(url string) http.Handler {
+	return http.RedirectHandler(url, http.StatusSeeOther)
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.URL.Query().Get(h.opts.Param), 10, 64)
+	if err != nil || id <= 0 {
+		h.opts.Rejected.ServeHTTP(w, r)
+		return
+	}
+
+	ctx := r.Context()
+
+	// The e-shop is asked first, so that arbitrary IDs are never looked up at GoPay.
+	orderNumber, err := h.opts.Lookup(ctx, id)
+	if errors.Is(err, ErrUnknownPayment) || (err == nil && orderNumber == "") {
+		h.opts.Rejected.ServeHTTP(w, r)
+		return
+	}
+	if err != nil {
+		h.opts.Error(w, r, err)
+		return
+	}
+
+	payment, err := h.payment(ctx, id)
+	if err != nil {
+		h.opts.Error(w, r, err)
+		return
+	}
+
+	if payment.OrderNumber != orderNumber || (h.opts.GoId != 0 && (payment.Target == nil || payment.Target.GoId != h.opts.GoId)) {
+		h.opts.Rejected.ServeHTTP(w, r)
+		return
+	}
+
+	r = r.WithContext(context.WithValue(ctx, paymentKey{}, payment))
+
+	if !payment.State.IsFinal() {
+		h.opts.Pending.ServeHTTP(w, r)
+		return
+	}
+	if route, ok := h.opts.Routes[payment.State]; ok {
+		route.ServeHTTP(w, r)
+		return
+	}
+	h.opts.Fallback.ServeHTTP(w, r)
+}
+
+func (h *handler) payment(ctx context.Context, id int64) (*paymentApi.PaymentResponse, error) {
+	if h.opts.WaitTimeout <= 0 {
+		return h.payments.GetPayment(ctx, id)
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, h.opts.WaitTimeout)
+	defer cancel()
+
+	result, err := h.payments.WaitForState(waitCtx, id, nil)
+	var finalErr *gopay.FinalStateError
+	switch {
+	case err == nil, errors.As(err, &finalErr):
+		return result.Payment, nil
+	case errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil && result.Payment != nil:
+		return result.Payment, nil
+	}
+	return nil, err
+}
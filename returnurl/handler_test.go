@@ -0,0 +1,95 @@
+package returnurl
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/tkliner/go-gopay"
+	paymentApi "github.com/tkliner/go-gopay/apis/payment"
+)
+
+type stubPayments struct {
+	gopay.PaymentInterface
+	payments map[int64]*paymentApi.PaymentResponse
+	calls    int
+}
+
+func (s *stubPayments) GetPayment(ctx context.Context, id int64, _ ...gopay.CallOption) (*paymentApi.PaymentResponse, error) {
+	s.calls++
+	p, ok := s.payments[id]
+	if !ok {
+		return nil, fmt.Errorf("payment %d not found", id)
+	}
+	return p, nil
+}
+
+func textHandler(text string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p, _ := PaymentFromContext(r.Context())
+		fmt.Fprintf(w, "%s %d", text, p.Id)
+	})
+}
+
+func TestHandler(t *testing.T) {
+	stub := &stubPayments{payments: map[int64]*paymentApi.PaymentResponse{
+		1: {Id: 1, OrderNumber: "A-1", State: paymentApi.StatePaid, Target: &paymentApi.Target{GoId: 8123456789}},
+		2: {Id: 2, OrderNumber: "A-2", State: paymentApi.StateCreated, Target: &paymentApi.Target{GoId: 8123456789}},
+		3: {Id: 3, OrderNumber: "A-3", State: paymentApi.StateCanceled, Target: &paymentApi.Target{GoId: 8123456789}},
+		4: {Id: 4, OrderNumber: "A-4", State: paymentApi.StatePaid, Target: &paymentApi.Target{GoId: 1}},
+		5: {Id: 5, OrderNumber: "B-5", State: paymentApi.StatePaid, Target: &paymentApi.Target{GoId: 8123456789}},
+	}}
+
+	orders := map[int64]string{1: "A-1", 2: "A-2", 3: "A-3", 4: "A-4", 5: "A-5"}
+
+	h, err := NewHandler(stub, Options{
+		GoId: 8123456789,
+		Lookup: func(ctx context.Context, id int64) (string, error) {
+			if o, ok := orders[id]; ok {
+				return o, nil
+			}
+			return "", ErrUnknownPayment
+		},
+		Routes: map[paymentApi.PaymentState]http.Handler{
+			paymentApi.StatePaid: textHandler("paid"),
+		},
+		Pending:  textHandler("pending"),
+		Fallback: textHandler("failed"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		query  string
+		status int
+		body   string
+	}{
+		{"?id=1", http.StatusOK, "paid 1"},
+		{"?id=2", http.StatusOK, "pending 2"},
+		{"?id=3", http.StatusOK, "failed 3"},
+		{"?id=4", http.StatusNotFound, ""},  // foreign e-shop
+		{"?id=5", http.StatusNotFound, ""},  // order number mismatch
+		{"?id=99", http.StatusNotFound, ""}, // unknown to the e-shop
+		{"?id=abc", http.StatusNotFound, ""},
+		{"", http.StatusNotFound, ""},
+	}
+
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/return"+tt.query, nil))
+
+		if rec.Code != tt.status {
+			t.Errorf("%s: status %d, want %d", tt.query, rec.Code, tt.status)
+		}
+		if tt.body != "" && rec.Body.String() != tt.body {
+			t.Errorf("%s: body %q, want %q", tt.query, rec.Body.String(), tt.body)
+		}
+	}
+
+	if stub.calls != 5 {
+		t.Errorf("GoPay should be asked only for payments known to the e-shop, got %d calls", stub.calls)
+	}
+}
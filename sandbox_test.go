@@ -0,0 +1,52 @@
+//go:build sandbox
+
+package gopay
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/tkliner/go-gopay/client/config"
+)
+
+// TestMock looks up a payment on the GoPay sandbox. It runs with -tags sandbox and
+// reads the credentials and the payment from GOPAY_GOID, GOPAY_CLIENT_ID,
+// GOPAY_CLIENT_SECRET and GOPAY_PAYMENT_ID.
+func TestMock(t *testing.T) {
+	goId := sandboxEnvInt(t, "GOPAY_GOID")
+	paymentId := sandboxEnvInt(t, "GOPAY_PAYMENT_ID")
+
+	cfg := config.NewConfig(
+		config.WithGatewayURL("https://gw.sandbox.gopay.com"),
+		config.WithCredentials(goId, os.Getenv("GOPAY_CLIENT_ID"), os.Getenv("GOPAY_CLIENT_SECRET")),
+	)
+
+	client, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close(context.Background())
+
+	resp, err := client.Payment().GetPayment(context.Background(), paymentId)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Logf("Response: %+v", resp)
+}
+
+func sandboxEnvInt(t *testing.T, name string) int64 {
+	t.Helper()
+
+	v := os.Getenv(name)
+	if v == "" {
+		t.Skipf("%s is not set", name)
+	}
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+	return n
+}
@@ -0,0 +1,107 @@
+package gopay
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/tkliner/go-gopay/client/auth"
+	"github.com/tkliner/go-gopay/client/config"
+	gopayHttp "github.com/tkliner/go-gopay/client/http"
+)
+
+// newScopedGateway issues a token named after the requested scope and records the
+// token used for each API path.
+func newScopedGateway(t *testing.T) (*httptest.Server, map[string]string, *int) {
+	t.Helper()
+
+	var mu sync.Mutex
+	used := map[string]string{}
+	issued := 0
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if r.URL.Path == "/api/oauth2/token" {
+			r.ParseForm()
+			issued++
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"token_type":"bearer","access_token":"` + r.PostForm.Get("scope") + `","expires_in":1800}`))
+			return
+		}
+		used[r.Method+" "+r.URL.Path] = r.Header.Get("Authorization")
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id": 1001, "state": "CREATED"}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, used, &issued
+}
+
+func TestPerScopeTokens(t *testing.T) {
+	srv, used, issued := newScopedGateway(t)
+	c := newTestClient(t, srv)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if _, err := c.Payment().CreatePayment(ctx, testPayment("A-1")); err != nil {
+			t.Fatalf("CreatePayment: %v", err)
+		}
+		if _, err := c.Payment().GetPayment(ctx, 1001); err != nil {
+			t.Fatalf("GetPayment: %v", err)
+		}
+	}
+
+	if got := used["POST /api/payments/payment"]; got != "Bearer payment-create" {
+		t.Errorf("create used %q", got)
+	}
+	if got := used["GET /api/payments/payment/1001"]; got != "Bearer payment-all" {
+		t.Errorf("get used %q", got)
+	}
+	if *issued != 2 {
+		t.Errorf("expected one token per scope, %d issued", *issued)
+	}
+}
+
+func TestScopeNotGranted(t *testing.T) {
+	srv, used, _ := newScopedGateway(t)
+	c := newTestClient(t, srv, config.WithScope(config.TokenScopeCreatePayment))
+
+	_, err := c.Payment().GetPayment(context.Background(), 1001)
+
+	var scopeErr *auth.ScopeError
+	if !errors.As(err, &scopeErr) {
+		t.Fatalf("expected scope error, got %v", err)
+	}
+	if scopeErr.Required != config.TokenScopeAll {
+		t.Errorf("unexpected required scope %s", scopeErr.Required)
+	}
+	if len(used) != 0 {
+		t.Errorf("request was sent: %v", used)
+	}
+}
+
+func TestScopeNotGrantedKeepsCircuitClosed(t *testing.T) {
+	srv, _, _ := newScopedGateway(t)
+	c := newTestClient(t, srv,
+		config.WithScope(config.TokenScopeCreatePayment),
+		config.WithCircuitBreaker(config.CircuitBreaker{MinRequests: 3}),
+	)
+
+	for i := 0; i < 3; i++ {
+		if _, err := c.Payment().GetPayment(context.Background(), 1001); !errors.As(err, new(*auth.ScopeError)) {
+			t.Fatalf("expected scope error, got %v", err)
+		}
+	}
+
+	if state := c.CircuitState(); state != gopayHttp.CircuitClosed {
+		t.Errorf("scope errors opened the circuit: %s", state)
+	}
+	if _, err := c.Payment().CreatePayment(context.Background(), testPayment("A-1")); err != nil {
+		t.Errorf("CreatePayment: %v", err)
+	}
+}
@@ -0,0 +1,138 @@
+package gopay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/tkliner/go-gopay/client"
+	"github.com/tkliner/go-gopay/client/auth"
+	"github.com/tkliner/go-gopay/client/config"
+)
+
+// Reasons of a failed startup check, matched by errors.Is on the *StartupError.
+var (
+	ErrBadCredentials   = errors.New("client credentials were rejected")
+	ErrWrongScope       = errors.New("token scope is not allowed for the client")
+	ErrWrongEnvironment = errors.New("gateway URL does not match the environment")
+	ErrUnknownGoId      = errors.New("GoID is unknown or does not belong to the client")
+)
+
+// StartupError is returned by New with config.WithEagerStartup when the gateway
+// rejects the configuration.
+type StartupError struct {
+	// Reason is one of the Err* variables above, nil if the check failed otherwise,
+	// e.g. because the gateway is unreachable.
+	Reason error
+	// Hint suggests how to fix the configuration, if there is a likely cause.
+	Hint string
+	Err  error
+}
+
+func (e *StartupError) Error() string {
+	msg := "gopay startup check failed"
+	if e.Reason != nil {
+		msg += ": " + e.Reason.Error()
+	}
+	if e.Hint != "" {
+		msg += " (" + e.Hint + ")"
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *StartupError) Unwrap() []error {
+	var errs []error
+	if e.Reason != nil {
+		errs = append(errs, e.Reason)
+	}
+	if e.Err != nil {
+		errs = append(errs, e.Err)
+	}
+	return errs
+}
+
+// Warmup fetches the access token and opens the connections to the gateway ahead
+// of the first call.
+func (g *GoPay) Warmup(ctx context.Context) error {
+	return g.ping(ctx)
+}
+
+// checkStartup verifies the configuration with the gateway, see config.WithEagerStartup.
+func (g *GoPay) checkStartup(ctx context.Context, cfg *config.Config) error {
+	if err := checkEnvironment(cfg); err != nil {
+		return err
+	}
+
+	if _, err := g.authenticator.GetAccessToken(ctx); err != nil {
+		return tokenStartupError(cfg, err)
+	}
+
+	err := g.ping(ctx)
+
+	var tokenErr *auth.TokenError
+	var statusErr *client.StatusError
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &tokenErr):
+		return tokenStartupError(cfg, err)
+	case errors.As(err, &statusErr) && (statusErr.StatusCode == http.StatusForbidden || statusErr.StatusCode == http.StatusNotFound):
+		return &StartupError{Reason: ErrUnknownGoId, Hint: fmt.Sprintf("GoID %d, %s", cfg.GoId, environmentHint(cfg)), Err: err}
+	case errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusUnauthorized:
+		return &StartupError{Reason: ErrBadCredentials, Hint: environmentHint(cfg), Err: err}
+	default:
+		return &StartupError{Err: err}
+	}
+}
+
+// checkEnvironment detects a GoPay gateway URL not matching config.WithProduction.
+func checkEnvironment(cfg *config.Config) error {
+	u, err := url.Parse(cfg.GatewayURL)
+	if err != nil {
+		return &StartupError{Err: err}
+	}
+
+	switch {
+	case cfg.IsProduction && sameHost(u, config.SandboxGatewayURL):
+		return &StartupError{Reason: ErrWrongEnvironment, Hint: "production is configured with the sandbox gateway"}
+	case !cfg.IsProduction && sameHost(u, config.ProductionGatewayURL):
+		return &StartupError{Reason: ErrWrongEnvironment, Hint: "sandbox is configured with the production gateway, use config.WithProduction"}
+	}
+	return nil
+}
+
+// sameHost reports whether u points to the host of the gateway URL.
+func sameHost(u *url.URL, gatewayURL string) bool {
+	gateway, err := url.Parse(gatewayURL)
+	return err == nil && u.Hostname() == gateway.Hostname()
+}
+
+func tokenStartupError(cfg *config.Config, err error) error {
+	var tokenErr *auth.TokenError
+	var scopeErr *auth.ScopeError
+
+	switch {
+	case errors.As(err, &scopeErr):
+		return &StartupError{Reason: ErrWrongScope, Err: err}
+	case errors.As(err, &tokenErr) && tokenErr.Code == auth.TokenErrorInvalidScope:
+		return &StartupError{Reason: ErrWrongScope, Hint: fmt.Sprintf("scope %s", cfg.Scope), Err: err}
+	case errors.As(err, &tokenErr) && tokenErr.StatusCode < 500:
+		return &StartupError{Reason: ErrBadCredentials, Hint: environmentHint(cfg), Err: err}
+	default:
+		return &StartupError{Err: err}
+	}
+}
+
+// environmentHint points out that the credentials may belong to the other GoPay
+// environment, a common cause of rejected credentials.
+func environmentHint(cfg *config.Config) string {
+	if cfg.IsProduction {
+		return "check that the credentials are not from the sandbox"
+	}
+	return "check that the credentials are not from production"
+}
@@ -0,0 +1,141 @@
+package gopay
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/tkliner/go-gopay/client/config"
+)
+
+type startupGateway struct {
+	token, instruments int
+	tokenBody          string
+	tokens, calls      atomic.Int32
+}
+
+func (g *startupGateway) start(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/api/oauth2/token" {
+			g.tokens.Add(1)
+			w.WriteHeader(g.token)
+			w.Write([]byte(g.tokenBody))
+			return
+		}
+		g.calls.Add(1)
+		w.WriteHeader(g.instruments)
+		w.Write([]byte(`{"groups": {}, "enabledPaymentInstruments": []}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+const startupToken = `{"token_type":"bearer","access_token":"test-token","expires_in":1800}`
+
+func newEagerClient(gatewayURL string, opts ...config.Option) (Clienter, error) {
+	opts = append([]config.Option{
+		config.WithGatewayURL(gatewayURL),
+		config.WithCredentials(8123456789, "client", "secret"),
+		config.WithEagerStartup(),
+	}, opts...)
+	return New(config.NewConfig(opts...))
+}
+
+func TestEagerStartup(t *testing.T) {
+	g := &startupGateway{token: http.StatusOK, tokenBody: startupToken, instruments: http.StatusOK}
+	srv := g.start(t)
+
+	c, err := newEagerClient(srv.URL)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer c.Close(context.Background())
+
+	if g.tokens.Load() == 0 || g.calls.Load() != 1 {
+		t.Errorf("startup did not contact the gateway: %d tokens, %d calls", g.tokens.Load(), g.calls.Load())
+	}
+}
+
+func TestEagerStartupErrors(t *testing.T) {
+	tests := map[string]struct {
+		gateway *startupGateway
+		want    error
+	}{
+		"bad credentials": {&startupGateway{token: http.StatusUnauthorized, tokenBody: `{"errors":[{"error_code":202}]}`}, ErrBadCredentials},
+		"wrong scope":     {&startupGateway{token: http.StatusBadRequest, tokenBody: `{"error":"invalid_scope"}`}, ErrWrongScope},
+		"scope in text":   {&startupGateway{token: http.StatusBadRequest, tokenBody: `{"error":"invalid_client","error_description":"unknown client for scope"}`}, ErrBadCredentials},
+		"unknown goid":    {&startupGateway{token: http.StatusOK, tokenBody: startupToken, instruments: http.StatusNotFound}, ErrUnknownGoId},
+		"foreign goid":    {&startupGateway{token: http.StatusOK, tokenBody: startupToken, instruments: http.StatusForbidden}, ErrUnknownGoId},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			srv := tt.gateway.start(t)
+
+			c, err := newEagerClient(srv.URL, config.WithAutoRefresh())
+
+			var startupErr *StartupError
+			if c != nil || !errors.As(err, &startupErr) || !errors.Is(err, tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, err)
+			}
+			if startupErr.Hint == "" {
+				t.Errorf("missing hint: %v", err)
+			}
+			verifyNoLeaks(t)
+		})
+	}
+}
+
+func TestEagerStartupWrongEnvironment(t *testing.T) {
+	_, err := newEagerClient("https://gw.sandbox.gopay.com", config.WithProduction())
+	if !errors.Is(err, ErrWrongEnvironment) {
+		t.Fatalf("expected ErrWrongEnvironment, got %v", err)
+	}
+
+	_, err = newEagerClient("https://gate.gopay.cz")
+	if !errors.Is(err, ErrWrongEnvironment) {
+		t.Fatalf("expected ErrWrongEnvironment, got %v", err)
+	}
+}
+
+func TestEagerStartupUnreachable(t *testing.T) {
+	_, err := newEagerClient("http://127.0.0.1:1")
+
+	var startupErr *StartupError
+	if !errors.As(err, &startupErr) || startupErr.Reason != nil {
+		t.Fatalf("expected startup error without reason, got %v", err)
+	}
+}
+
+func TestLazyStartup(t *testing.T) {
+	g := &startupGateway{token: http.StatusUnauthorized}
+	srv := g.start(t)
+
+	c := newTestClient(t, srv)
+	if g.tokens.Load() != 0 {
+		t.Error("lazy client contacted the gateway")
+	}
+
+	if err := c.Warmup(context.Background()); err == nil {
+		t.Error("expected Warmup to fail with rejected credentials")
+	}
+}
+
+func TestWarmup(t *testing.T) {
+	g := &startupGateway{token: http.StatusOK, tokenBody: startupToken, instruments: http.StatusOK}
+	srv := g.start(t)
+	c := newTestClient(t, srv)
+
+	if err := c.Warmup(context.Background()); err != nil {
+		t.Fatalf("Warmup: %v", err)
+	}
+	if g.tokens.Load() == 0 || g.calls.Load() != 1 {
+		t.Errorf("warmup did not contact the gateway: %d tokens, %d calls", g.tokens.Load(), g.calls.Load())
+	}
+}
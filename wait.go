@@ -0,0 +1,146 @@
+package gopay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	paymentApi "github.com/tkliner/go-gopay/apis/payment"
+	"github.com/tkliner/go-gopay/client"
+	gopayHttp "github.com/tkliner/go-gopay/client/http"
+)
+
+// waitBackoff controls how often WaitForState polls the payment status.
+var waitBackoff = struct {
+	initial    time.Duration
+	max        time.Duration
+	multiplier float64
+}{
+	initial:    500 * time.Millisecond,
+	max:        5 * time.Second,
+	multiplier: 1.5,
+}
+
+// StateChange is a payment state observed while waiting.
+type StateChange struct {
+	State      paymentApi.PaymentState
+	SubState   paymentApi.SubState
+	ObservedAt time.Time
+}
+
+// WaitResult holds the last observed payment and every state change seen while waiting.
+type WaitResult struct {
+	Payment *paymentApi.PaymentResponse
+	History []StateChange
+}
+
+// FinalStateError is returned by WaitForState when the payment reached a final state
+// other than the awaited ones, e.g. CANCELED while waiting for PAID.
+type FinalStateError struct {
+	State paymentApi.PaymentState
+}
+
+func (e *FinalStateError) Error() string {
+	return fmt.Sprintf("payment reached final state %s", e.State)
+}
+
+// WaitForState polls the payment with increasing intervals until it reaches one of the
+// target states or any final state. Without targets it waits for a final state. When
+// ctx is done, the last observation is returned together with the context error, which
+// wraps the last error of the polls, if any. Only transient errors are retried: 5xx and
+// 429 responses, network errors and an open circuit breaker.
+func (p *payment) WaitForState(ctx context.Context, id int64, targetStates []paymentApi.PaymentState, opts ...CallOption) (*WaitResult, error) {
+	result := &WaitResult{}
+	interval := waitBackoff.initial
+
+	var lastErr error
+	for {
+		resp, err := p.GetPayment(ctx, id, opts...)
+
+		switch {
+		case err == nil:
+			lastErr = nil
+			result.Payment = resp
+			if n := len(result.History); n == 0 || result.History[n-1].State != resp.State || result.History[n-1].SubState != resp.SubState {
+				result.History = append(result.History, StateChange{
+					State:      resp.State,
+					SubState:   resp.SubState,
+					ObservedAt: time.Now(),
+				})
+			}
+
+			if isTargetState(resp.State, targetStates) {
+				return result, nil
+			}
+			if resp.State.IsFinal() {
+				return result, &FinalStateError{State: resp.State}
+			}
+		case ctx.Err() != nil:
+			return result, waitError(ctx, lastErr)
+		case !transient(err):
+			return result, err
+		default:
+			lastErr = err
+		}
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return result, waitError(ctx, lastErr)
+		case <-timer.C:
+		}
+
+		interval = time.Duration(float64(interval) * waitBackoff.multiplier)
+		if interval > waitBackoff.max {
+			interval = waitBackoff.max
+		}
+	}
+}
+
+// transient reports whether a poll failing with err may succeed when repeated.
+func transient(err error) bool {
+	var (
+		statusErr *client.StatusError
+		authErr   *gopayHttp.AuthError
+		urlErr    *url.Error
+		netErr    net.Error
+	)
+	switch {
+	case errors.As(err, &statusErr):
+		return statusErr.StatusCode >= 500 || statusErr.StatusCode == http.StatusTooManyRequests
+	case errors.As(err, &authErr), errors.Is(err, ErrClosed):
+		return false
+	case errors.Is(err, gopayHttp.ErrCircuitOpen), errors.Is(err, io.ErrUnexpectedEOF), errors.Is(err, io.EOF):
+		return true
+	}
+	// *url.Error is a net.Error itself, whatever it wraps.
+	if errors.As(err, &urlErr) {
+		err = urlErr.Err
+	}
+	return errors.As(err, &netErr)
+}
+
+func waitError(ctx context.Context, lastErr error) error {
+	if lastErr == nil {
+		return ctx.Err()
+	}
+	return fmt.Errorf("%w, last error: %w", ctx.Err(), lastErr)
+}
+
+func isTargetState(state paymentApi.PaymentState, targets []paymentApi.PaymentState) bool {
+	if len(targets) == 0 {
+		return state.IsFinal()
+	}
+	for _, target := range targets {
+		if state == target {
+			return true
+		}
+	}
+	return false
+}
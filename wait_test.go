@@ -0,0 +1,147 @@
+package gopay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	paymentApi "github.com/tkliner/go-gopay/apis/payment"
+	"github.com/tkliner/go-gopay/client"
+	gopayHttp "github.com/tkliner/go-gopay/client/http"
+)
+
+// fastWaitBackoff shortens the poll intervals for the duration of the test.
+func fastWaitBackoff(t *testing.T) {
+	saved := waitBackoff
+	t.Cleanup(func() { waitBackoff = saved })
+	waitBackoff.initial, waitBackoff.max = time.Millisecond, 5*time.Millisecond
+}
+
+func TestWaitForState(t *testing.T) {
+	fastWaitBackoff(t)
+
+	states := []string{"CREATED", "CREATED", "PAYMENT_METHOD_CHOSEN", "PAID"}
+	var polls atomic.Int32
+
+	srv := newTestGateway(t, func(w http.ResponseWriter, r *http.Request) {
+		n := int(polls.Add(1)) - 1
+		if n >= len(states) {
+			n = len(states) - 1
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"id": 1, "state": %q}`, states[n])
+	})
+
+	p := newTestClient(t, srv).Payment()
+
+	result, err := p.WaitForState(context.Background(), 1, []paymentApi.PaymentState{paymentApi.StatePaid})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Payment.State != paymentApi.StatePaid || len(result.History) != 3 || polls.Load() != 4 {
+		t.Errorf("unexpected result %+v after %d polls", result, polls.Load())
+	}
+
+	// PAID is final, waiting for AUTHORIZED ends with an error.
+	_, err = p.WaitForState(context.Background(), 1, []paymentApi.PaymentState{paymentApi.StateAuthorized})
+	var finalErr *FinalStateError
+	if !errors.As(err, &finalErr) || finalErr.State != paymentApi.StatePaid {
+		t.Errorf("expected FinalStateError, got %v", err)
+	}
+}
+
+func TestWaitForStateDeadline(t *testing.T) {
+	fastWaitBackoff(t)
+
+	srv := newTestGateway(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id": 1, "state": "CREATED"}`))
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+
+	result, err := newTestClient(t, srv).Payment().WaitForState(ctx, 1, nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline, got %v", err)
+	}
+	if result.Payment == nil || result.Payment.State != paymentApi.StateCreated || len(result.History) != 1 {
+		t.Errorf("unexpected result %+v", result)
+	}
+}
+
+func TestWaitForStateErrors(t *testing.T) {
+	fastWaitBackoff(t)
+
+	var polls atomic.Int32
+	srv := newTestGateway(t, func(w http.ResponseWriter, r *http.Request) {
+		polls.Add(1)
+		switch r.Header.Get("X-Test") {
+		case "unavailable":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			// 200 without a body cannot be decoded, polling again will not help.
+			w.WriteHeader(http.StatusOK)
+		}
+	})
+	p := newTestClient(t, srv).Payment()
+
+	_, err := p.WaitForState(context.Background(), 1, nil, WithHeader("X-Test", "empty"))
+	if !errors.Is(err, client.ErrEmptyBody) || polls.Load() != 1 {
+		t.Errorf("expected ErrEmptyBody after one poll, got %v after %d polls", err, polls.Load())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+
+	polls.Store(0)
+	_, err = p.WaitForState(ctx, 1, nil, WithHeader("X-Test", "unavailable"))
+	var statusErr *client.StatusError
+	if !errors.Is(err, context.DeadlineExceeded) || !errors.As(err, &statusErr) || polls.Load() < 2 {
+		t.Errorf("expected deadline wrapping the 503 after retries, got %v after %d polls", err, polls.Load())
+	}
+}
+
+func TestWaitForStateClosed(t *testing.T) {
+	srv := newTestGateway(t, func(w http.ResponseWriter, r *http.Request) {})
+	c := newTestClient(t, srv)
+	c.Close(context.Background())
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if _, err := c.Payment().WaitForState(ctx, 1, nil); !errors.Is(err, ErrClosed) {
+		t.Errorf("expected ErrClosed, got %v", err)
+	}
+}
+
+func TestTransient(t *testing.T) {
+	tests := map[string]struct {
+		err  error
+		want bool
+	}{
+		"server error":  {&client.StatusError{StatusCode: http.StatusBadGateway}, true},
+		"too many":      {&client.StatusError{StatusCode: http.StatusTooManyRequests}, true},
+		"not found":     {&client.StatusError{StatusCode: http.StatusNotFound}, false},
+		"network":       {&url.Error{Op: "Get", Err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}}, true},
+		"circuit open":  {&url.Error{Op: "Get", Err: &gopayHttp.CircuitOpenError{}}, true},
+		"closed":        {&url.Error{Op: "Get", Err: ErrClosed}, false},
+		"auth":          {&url.Error{Op: "Get", Err: &gopayHttp.AuthError{Err: errors.New("invalid_client")}}, false},
+		"empty body":    {client.ErrEmptyBody, false},
+		"unknown field": {&client.UnknownFieldsError{Fields: []string{"x"}}, false},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := transient(tt.err); got != tt.want {
+				t.Errorf("transient(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}